@@ -0,0 +1,654 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"transaction-filter-backend/dynamictablefilter"
+	"transaction-filter-backend/ent/test3schema"
+	"transaction-filter-backend/filterast"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+)
+
+// conformanceRow is one record of the conformance fixture, stored both as a
+// Test3Schema entity and as a row of an equivalent file-based table. nil
+// pointers are nulls.
+type conformanceRow struct {
+	sku, productName         string
+	shortDesc, tags          *string
+	costPrice, retailPrice   float64
+	stockCount               int
+	isActive                 bool
+	publishedAt, lastOrdered *time.Time
+}
+
+func strPtr(s string) *string { return &s }
+
+func timePtr(year int, month time.Month, day, hour, min, sec int) *time.Time {
+	t := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	return &t
+}
+
+var conformanceRows = []conformanceRow{
+	{sku: "CF-01", productName: "Café Zoë", shortDesc: strPtr("Espresso bar"), tags: strPtr("food, drink"),
+		costPrice: 2.5, retailPrice: 4, stockCount: 10, isActive: true, publishedAt: timePtr(2024, time.March, 1, 12, 0, 0)},
+	{sku: "CF-02", productName: "CAFE ZOE", shortDesc: strPtr(""),
+		costPrice: 3, retailPrice: 3, stockCount: 0, publishedAt: timePtr(2024, time.March, 1, 0, 0, 0), lastOrdered: timePtr(2024, time.April, 10, 8, 30, 0)},
+	{sku: "CF-03", productName: "Straße 9", tags: strPtr("home"),
+		costPrice: 10, retailPrice: 25.5, stockCount: 7, isActive: true, lastOrdered: timePtr(2024, time.January, 15, 0, 0, 0)},
+	{sku: "cf-04", productName: "Lamp", shortDesc: strPtr("Desk lamp"), tags: strPtr("home, light"),
+		costPrice: 12, retailPrice: 11, stockCount: 3, isActive: true, publishedAt: timePtr(2023, time.December, 31, 23, 59, 59)},
+	{sku: "CF-05", productName: "lamp shade", shortDesc: strPtr("Shade"), tags: strPtr("light"),
+		costPrice: 4, retailPrice: 9, stockCount: 100, publishedAt: timePtr(2024, time.June, 15, 0, 0, 0), lastOrdered: timePtr(2024, time.June, 15, 10, 0, 0)},
+}
+
+// conformanceRawRows are records holding values of another type than their
+// field, which SQLite stores as given: a fractional int and a number in a
+// string field. ent cannot load them into entities, so only
+// TestEngineConformance adds them, reading back just the skus.
+var conformanceRawRows = []map[string]interface{}{
+	{"sku": "CF-06", "product_name": 42, "cost_price": 2.0, "retail_price": 5.0, "stock_count": 2.5, "is_active": false},
+}
+
+// conformanceCorpus lists filters, with optional sort criteria and a
+// request collation, that must select the same records in the same order
+// from both engines. Without a sort only the set of records is compared.
+var conformanceCorpus = []struct {
+	name      string
+	filter    string
+	sort      string
+	collation string
+}{
+	{name: "string equals is exact by default", filter: `["product_name", "=", "café zoë"]`},
+	{name: "string equals case-insensitive", filter: `["product_name", "=", "café zoë"]`, collation: filterast.CollationCaseInsensitive},
+	{name: "string not equal", filter: `["product_name", "<>", "LAMP"]`},
+	{name: "shorthand equality", filter: `["sku", "cf-04"]`},
+	{name: "contains", filter: `["product_name", "contains", "AMP"]`},
+	{name: "notcontains skips nulls", filter: `["short_description", "notcontains", "a"]`},
+	{name: "startswith folds ß", filter: `["product_name", "startswith", "strasse"]`},
+	{name: "endswith", filter: `["tags", "endswith", "LIGHT"]`},
+	{name: "like", filter: `["product_name", "like", "%a_e%"]`},
+	{name: "matches", filter: `["sku", "matches", "^CF-0[1-3]$"]`},
+	{name: "fuzzy", filter: `["product_name", "fuzzy", {"value": "lmp", "distance": 1}]`},
+	{name: "anyof strings", filter: `["sku", "anyof", ["CF-01", "CF-04", "nope"]]`},
+	{name: "noneof skips nulls", filter: `["short_description", "noneof", ["shade"]]`},
+	{name: "isblank string", filter: `["short_description", "isblank", null]`},
+	{name: "isnotblank string", filter: `["short_description", "isnotblank", null]`},
+	{name: "equals null", filter: `["tags", "=", null]`},
+	{name: "not equals null", filter: `["tags", "<>", null]`},
+	{name: "int comparison", filter: `["stock_count", ">", 5]`},
+	{name: "int between", filter: `["stock_count", "between", [3, 10]]`},
+	{name: "int anyof", filter: `["stock_count", "anyof", [0, 7]]`},
+	{name: "float comparison", filter: `["retail_price", ">=", 9]`},
+	{name: "float between", filter: `["cost_price", "between", [2.5, 4]]`},
+	{name: "float equals int value", filter: `["retail_price", "=", 4]`},
+	{name: "bool", filter: `["is_active", false]`},
+	{name: "time after", filter: `["published_at", ">", "2024-01-01T00:00:00Z"]`},
+	{name: "whole day equals", filter: `["published_at", "=", "2024-03-01"]`},
+	{name: "whole day not equal skips nulls", filter: `["published_at", "<>", "2024-03-01"]`},
+	{name: "time between", filter: `["last_ordered_at", "between", ["2024-01-01", "2024-04-10"]]`},
+	{name: "date part", filter: `["published_at.Month", "anyof", [3, 12]]`},
+	{name: "isblank time", filter: `["last_ordered_at", "isblank", null]`},
+	{name: "field comparison", filter: `["retail_price", ">", {"field": "cost_price"}]`},
+	{name: "field comparison with nulls", filter: `["published_at", "<", {"field": "last_ordered_at"}]`},
+	{name: "computed number", filter: `["margin", "<", 0]`},
+	{name: "computed string", filter: `["full_label", "startswith", "cf-0"]`},
+	{name: "computed over a fractional int", filter: `["stock_value", "=", 5]`},
+	{name: "computed over a number in a string field", filter: `["full_label", "=", "CF-06 42"]`},
+	{name: "negation skips nulls", filter: `["!", ["short_description", "contains", "e"]]`},
+	{name: "negated time skips nulls", filter: `["!", ["published_at", ">", "2024-01-01"]]`},
+	{name: "negated group", filter: `["!", [["tags", "contains", "home"], "or", ["published_at", "<", "2024-01-01"]]]`},
+	{name: "or with unknown", filter: `[["short_description", "=", "x"], "or", ["stock_count", ">", 50]]`},
+	{name: "and binds tighter than or", filter: `[["is_active", true], "or", ["stock_count", ">", 50], "and", ["tags", "contains", "light"]]`},
+	{name: "sort by string", sort: `["product_name"]`},
+	{name: "sort by number desc", sort: `[{"selector": "retail_price", "desc": true}]`},
+	{name: "sort with nulls first", filter: `["stock_count", ">", 0]`, sort: `["published_at"]`},
+	{name: "sort by bool then string", sort: `["is_active", "sku"]`},
+	{name: "sort by date part", sort: `["published_at.Month", {"selector": "sku", "desc": true}]`},
+	{name: "sort by computed field", sort: `["margin"]`},
+	{name: "sort case-insensitive", sort: `["product_name"]`, collation: filterast.CollationCaseInsensitive},
+}
+
+// TestEngineConformance runs conformanceCorpus against the Test3Schema
+// entity and a file-based table holding the same records and schema, in UTC
+// and another timezone, and checks that both engines agree.
+func TestEngineConformance(t *testing.T) {
+	ctx := context.Background()
+	insertConformanceRows(ctx)
+	insertRawConformanceRows(ctx, t)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	adapter, err := GetAdapter("test3schema")
+	if err != nil {
+		t.Fatalf("test3schema adapter not registered: %v", err)
+	}
+	schema, records := conformanceTable(t, conformanceRawRows...)
+
+	// Date parts and whole days are taken in the configured timezone; New
+	// York also checks the switch to daylight saving time.
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		newYork = time.FixedZone("UTC-5", -5*60*60)
+	}
+	defer filterast.SetTimezone(time.UTC)
+	for _, loc := range []*time.Location{time.UTC, newYork} {
+		filterast.SetTimezone(loc)
+		for _, tc := range conformanceCorpus {
+			t.Run(loc.String()+"/"+tc.name, func(t *testing.T) {
+				var filter, sortOption interface{}
+				for _, option := range []struct {
+					raw  string
+					into *interface{}
+				}{{tc.filter, &filter}, {tc.sort, &sortOption}} {
+					if option.raw != "" {
+						if err := json.Unmarshal([]byte(option.raw), option.into); err != nil {
+							t.Fatalf("bad corpus entry %q: %v", option.raw, err)
+						}
+					}
+				}
+
+				root, err := filterast.Parse(filter, adapter.Fields())
+				if err == nil {
+					err = filterast.SetCollation(root, tc.collation)
+				}
+				if err != nil {
+					t.Fatalf("ent: unexpected error: %v", err)
+				}
+				pred, err := BuildPredicate(adapter, root)
+				if err != nil {
+					t.Fatalf("ent: unexpected error: %v", err)
+				}
+				sorts, err := filterast.ParseSort(sortOption, adapter.Fields())
+				if err != nil {
+					t.Fatalf("ent: unexpected sort error: %v", err)
+				}
+				filterast.SetSortCollation(sorts, tc.collation)
+				query := testClient.Test3Schema.Query().Order(orderBySorts(adapter, sorts))
+				if pred != nil {
+					query = query.Where(func(s *sql.Selector) { s.Where(pred) })
+				}
+				entSkus, err := query.Select(test3schema.FieldSku).Strings(ctx)
+				if err != nil {
+					t.Fatalf("ent: query failed: %v", err)
+				}
+
+				root, err = filterast.Parse(filter, schema.FieldMap)
+				if err == nil {
+					err = filterast.SetCollation(root, tc.collation)
+				}
+				if err != nil {
+					t.Fatalf("dynamic: unexpected error: %v", err)
+				}
+				matched := dynamictablefilter.MatchDynamicData(records, schema, root)
+				sorts, err = filterast.ParseSort(sortOption, schema.FieldMap)
+				if err != nil {
+					t.Fatalf("dynamic: unexpected sort error: %v", err)
+				}
+				filterast.SetSortCollation(sorts, tc.collation)
+				dynamictablefilter.SortDynamicData(matched, sorts)
+				dynamicSkus := make([]string, len(matched))
+				for i, record := range matched {
+					dynamicSkus[i] = record["sku"].(string)
+				}
+
+				if tc.sort == "" {
+					sort.Strings(entSkus)
+					sort.Strings(dynamicSkus)
+				}
+				if fmt.Sprint(entSkus) != fmt.Sprint(dynamicSkus) {
+					t.Errorf("engines disagree: ent %v, dynamic %v", entSkus, dynamicSkus)
+				}
+			})
+		}
+	}
+}
+
+// TestLoadOptionsConformance sends the same load options to /filter for
+// Test3Schema and to the file-based table's filter endpoint and checks that
+// both return the same page and total count.
+func TestLoadOptionsConformance(t *testing.T) {
+	ctx := context.Background()
+	insertConformanceRows(ctx)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	originalPath := dynamictablefilter.GetBaseTablesPath()
+	dynamictablefilter.SetBaseTablesPath(filepath.Dir(writeConformanceTable(t)))
+	defer dynamictablefilter.SetBaseTablesPath(originalPath)
+
+	testCases := []struct {
+		name          string
+		options       string
+		expectedSkus  []string
+		expectedTotal int
+	}{
+		{name: "no load options", options: `"sort": ["sku"]`,
+			expectedSkus: []string{"CF-01", "CF-02", "CF-03", "CF-05", "cf-04"}, expectedTotal: -1},
+		{name: "first page", options: `"sort": ["sku"], "take": 2, "requireTotalCount": true`,
+			expectedSkus: []string{"CF-01", "CF-02"}, expectedTotal: 5},
+		{name: "skip only", options: `"sort": [{"selector": "retail_price", "desc": true}], "skip": 3`,
+			expectedSkus: []string{"CF-01", "CF-02"}, expectedTotal: -1},
+		{name: "filtered page", options: `"filter": ["is_active", true], "sort": [{"selector": "is_active"}, {"selector": "stock_count", "desc": true}], "skip": 1, "take": 1, "requireTotalCount": true`,
+			expectedSkus: []string{"CF-03"}, expectedTotal: 3},
+		{name: "skip past the end", options: `"skip": 10, "requireTotalCount": true`,
+			expectedSkus: []string{}, expectedTotal: 5},
+		{name: "count without paging", options: `"filter": ["stock_count", ">", 5], "sort": ["sku"], "requireTotalCount": true`,
+			expectedSkus: []string{"CF-01", "CF-03", "CF-05"}, expectedTotal: 3},
+		{name: "sort under the request's collation", options: `"sort": ["product_name"], "skip": 2, "take": 2, "collation": "case-insensitive"`,
+			expectedSkus: []string{"cf-04", "CF-05"}, expectedTotal: -1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for engine, send := range conformanceEndpoints(tc.options) {
+				rec := httptest.NewRecorder()
+				send(rec)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s: expected status 200, got %d: %s", engine, rec.Code, rec.Body.String())
+				}
+				var result struct {
+					Data       []map[string]interface{} `json:"data"`
+					TotalCount int                      `json:"totalCount"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+					t.Fatalf("%s: response is not a load result: %v: %s", engine, err, rec.Body.String())
+				}
+				skus := make([]string, len(result.Data))
+				for i, record := range result.Data {
+					skus[i], _ = record["sku"].(string)
+				}
+				if fmt.Sprint(skus) != fmt.Sprint(tc.expectedSkus) || result.TotalCount != tc.expectedTotal {
+					t.Errorf("%s: expected %v with total %d, got %v with total %d", engine, tc.expectedSkus, tc.expectedTotal, skus, result.TotalCount)
+				}
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	filterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(`{"entity": "test3schema", "take": -1}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative take, got %d", rec.Code)
+	}
+}
+
+// TestGroupingConformance sends the same group options to both filter
+// endpoints and checks the group trees they return.
+func TestGroupingConformance(t *testing.T) {
+	ctx := context.Background()
+	insertConformanceRows(ctx)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	originalPath := dynamictablefilter.GetBaseTablesPath()
+	dynamictablefilter.SetBaseTablesPath(filepath.Dir(writeConformanceTable(t)))
+	defer dynamictablefilter.SetBaseTablesPath(originalPath)
+
+	testCases := []struct {
+		name               string
+		options            string
+		expectedGroups     string
+		expectedGroupCount int
+		expectedTotal      int
+	}{
+		{name: "collapsed with counts",
+			options:        `"group": [{"selector": "is_active", "isExpanded": false}], "requireGroupCount": true, "requireTotalCount": true`,
+			expectedGroups: `false:2 true:3`, expectedGroupCount: 2, expectedTotal: 5},
+		{name: "numeric interval",
+			options:        `"group": [{"selector": "retail_price", "groupInterval": 10}], "sort": ["sku"]`,
+			expectedGroups: `0:3[CF-01 CF-02 CF-05] 10:1[cf-04] 20:1[CF-03]`, expectedGroupCount: -1, expectedTotal: -1},
+		{name: "date intervals",
+			options:        `"group": [{"selector": "published_at", "groupInterval": "year", "desc": true}, {"selector": "published_at", "groupInterval": "month"}], "sort": [{"selector": "sku", "desc": true}]`,
+			expectedGroups: `2024:3{3:2[CF-02 CF-01] 6:1[CF-05]} 2023:1{12:1[cf-04]} <nil>:1{<nil>:1[CF-03]}`, expectedGroupCount: -1, expectedTotal: -1},
+		{name: "strings in collation order, paged",
+			options:        `"group": [{"selector": "product_name", "isExpanded": false}], "skip": 1, "take": 2, "requireGroupCount": true`,
+			expectedGroups: `Café Zoë:1 Lamp:1`, expectedGroupCount: 5, expectedTotal: -1},
+		{name: "strings under the request's collation",
+			options:        `"group": [{"selector": "product_name", "isExpanded": false}], "collation": "case-insensitive"`,
+			expectedGroups: `CAFE ZOE:1 Café Zoë:1 Lamp:1 lamp shade:1 Straße 9:1`, expectedGroupCount: -1, expectedTotal: -1},
+		{name: "filtered and nested",
+			options:        `"filter": ["is_active", true], "group": ["is_active", {"selector": "stock_count", "groupInterval": 5}], "requireTotalCount": true`,
+			expectedGroups: `true:3{0:1[cf-04] 5:1[CF-03] 10:1[CF-01]}`, expectedGroupCount: -1, expectedTotal: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for engine, send := range conformanceEndpoints(tc.options) {
+				rec := httptest.NewRecorder()
+				send(rec)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s: expected status 200, got %d: %s", engine, rec.Code, rec.Body.String())
+				}
+				var result struct {
+					Data       []interface{} `json:"data"`
+					TotalCount int           `json:"totalCount"`
+					GroupCount int           `json:"groupCount"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+					t.Fatalf("%s: response is not a load result: %v: %s", engine, err, rec.Body.String())
+				}
+				if got := summarizeGroups(result.Data); got != tc.expectedGroups {
+					t.Errorf("%s: expected groups %s, got %s", engine, tc.expectedGroups, got)
+				}
+				if result.GroupCount != tc.expectedGroupCount || result.TotalCount != tc.expectedTotal {
+					t.Errorf("%s: expected groupCount %d and totalCount %d, got %d and %d",
+						engine, tc.expectedGroupCount, tc.expectedTotal, result.GroupCount, result.TotalCount)
+				}
+			}
+		})
+	}
+
+	for engine, send := range conformanceEndpoints(`"group": [{"selector": "stock_count", "groupInterval": "year"}]`) {
+		rec := httptest.NewRecorder()
+		send(rec)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400 for a date groupInterval on a number, got %d", engine, rec.Code)
+		}
+	}
+}
+
+// TestSummaryConformance checks total and group summaries returned by both
+// filter endpoints.
+func TestSummaryConformance(t *testing.T) {
+	ctx := context.Background()
+	insertConformanceRows(ctx)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	originalPath := dynamictablefilter.GetBaseTablesPath()
+	dynamictablefilter.SetBaseTablesPath(filepath.Dir(writeConformanceTable(t)))
+	defer dynamictablefilter.SetBaseTablesPath(originalPath)
+
+	testCases := []struct {
+		name            string
+		options         string
+		expectedSummary string
+		expectedGroups  string
+	}{
+		{name: "totals ignore paging",
+			options: `"filter": ["is_active", true], "take": 1, "totalSummary": [
+				{"selector": "retail_price", "summaryType": "sum"}, {"selector": "cost_price", "summaryType": "avg"},
+				{"selector": "published_at", "summaryType": "min"}, {"selector": "stock_count", "summaryType": "max"},
+				{"summaryType": "count"}]`,
+			expectedSummary: `[40.5 8.166666666666666 2023-12-31T23:59:59Z 10 3]`},
+		{name: "totals of no records",
+			options: `"filter": ["stock_count", ">", 1000], "totalSummary": [
+				{"selector": "retail_price", "summaryType": "sum"}, {"selector": "retail_price", "summaryType": "avg"},
+				{"selector": "last_ordered_at", "summaryType": "max"}, {"selector": "sku", "summaryType": "count"}]`,
+			expectedSummary: `[0 <nil> <nil> 0]`},
+		{name: "group summaries",
+			options: `"group": [{"selector": "is_active", "isExpanded": false}], "groupSummary": [
+				{"selector": "stock_count", "summaryType": "sum"}, {"selector": "retail_price", "summaryType": "avg"},
+				{"selector": "last_ordered_at", "summaryType": "max"}]`,
+			expectedGroups: `false:[100 6 2024-06-15T10:00:00Z] true:[20 13.5 2024-01-15T00:00:00Z]`},
+		{name: "group summaries roll up",
+			options: `"group": [{"selector": "published_at", "groupInterval": "year"}, {"selector": "published_at", "groupInterval": "month", "isExpanded": false}],
+				"groupSummary": [{"summaryType": "count"}, {"selector": "stock_count", "summaryType": "sum"}, {"selector": "published_at.Day", "summaryType": "min"}]`,
+			expectedGroups: `<nil>:[1 7 <nil>]{<nil>:[1 7 <nil>]} 2023:[1 3 31]{12:[1 3 31]} 2024:[3 110 1]{3:[2 10 1] 6:[1 100 15]}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for engine, send := range conformanceEndpoints(tc.options) {
+				rec := httptest.NewRecorder()
+				send(rec)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s: expected status 200, got %d: %s", engine, rec.Code, rec.Body.String())
+				}
+				var result struct {
+					Data    []interface{} `json:"data"`
+					Summary []interface{} `json:"summary"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+					t.Fatalf("%s: response is not a load result: %v: %s", engine, err, rec.Body.String())
+				}
+				if tc.expectedSummary != "" && fmt.Sprint(result.Summary) != tc.expectedSummary {
+					t.Errorf("%s: expected summary %s, got %v", engine, tc.expectedSummary, result.Summary)
+				}
+				if got := summarizeGroupSummaries(result.Data); tc.expectedGroups != "" && got != tc.expectedGroups {
+					t.Errorf("%s: expected group summaries %s, got %s", engine, tc.expectedGroups, got)
+				}
+			}
+		})
+	}
+
+	for _, options := range []string{
+		`"totalSummary": [{"selector": "product_name", "summaryType": "sum"}]`,
+		`"totalSummary": [{"selector": "stock_count", "summaryType": "median"}]`,
+		`"group": ["is_active"], "groupSummary": [{"selector": "is_active", "summaryType": "avg"}]`,
+	} {
+		for engine, send := range conformanceEndpoints(options) {
+			rec := httptest.NewRecorder()
+			send(rec)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected 400 for %s, got %d", engine, options, rec.Code)
+			}
+		}
+	}
+}
+
+// summarizeGroupSummaries renders decoded groups as "key:summary", followed
+// by their subgroups in braces.
+func summarizeGroupSummaries(items []interface{}) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		group := item.(map[string]interface{})
+		parts[i] = fmt.Sprintf("%v:%v", group["key"], group["summary"])
+		if children, ok := group["items"].([]interface{}); ok && len(children) > 0 {
+			if _, isGroup := children[0].(map[string]interface{})["summary"]; isGroup {
+				parts[i] += "{" + summarizeGroupSummaries(children) + "}"
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// TestDistinctValuesConformance lists the distinct values of fields of
+// Test3Schema and the equivalent file-based table.
+func TestDistinctValuesConformance(t *testing.T) {
+	ctx := context.Background()
+	insertConformanceRows(ctx)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	originalPath := dynamictablefilter.GetBaseTablesPath()
+	dynamictablefilter.SetBaseTablesPath(filepath.Dir(writeConformanceTable(t)))
+	defer dynamictablefilter.SetBaseTablesPath(originalPath)
+
+	testCases := []struct {
+		name           string
+		options        string
+		expectedValues string
+		expectedTotal  int
+	}{
+		{name: "strings in collation order", options: `"field": "product_name"`,
+			expectedValues: `CAFE ZOE:1 Café Zoë:1 Lamp:1 Straße 9:1 lamp shade:1`, expectedTotal: 5},
+		{name: "search", options: `"field": "product_name", "searchValue": "LAMP", "collation": "case-insensitive"`,
+			expectedValues: `Lamp:1 lamp shade:1`, expectedTotal: 2},
+		{name: "filter and search", options: `"field": "tags", "filter": ["is_active", true], "searchValue": "o"`,
+			expectedValues: `food, drink:1 home:1 home, light:1`, expectedTotal: 3},
+		{name: "empty string", options: `"field": "short_description", "filter": ["is_active", false]`,
+			expectedValues: `:1 Shade:1`, expectedTotal: 2},
+		{name: "numeric interval, paged", options: `"field": "retail_price", "groupInterval": 10, "skip": 1, "take": 1`,
+			expectedValues: `10:1`, expectedTotal: 3},
+		{name: "bool descending", options: `"field": "is_active", "desc": true`,
+			expectedValues: `true:3 false:2`, expectedTotal: 2},
+		{name: "date tree", options: `"field": "published_at"`,
+			expectedValues: `<nil>:1{<nil>:1{<nil>:1}} 2023:1{12:1{31:1}} 2024:3{3:2{1:2} 6:1{15:1}}`, expectedTotal: 3},
+		{name: "date tree to months", options: `"field": "published_at", "groupInterval": "month", "take": 1, "skip": 2`,
+			expectedValues: `2024:3{3:2 6:1}`, expectedTotal: 3},
+		{name: "date part", options: `"field": "last_ordered_at.Month"`,
+			expectedValues: `<nil>:2 1:1 4:1 6:1`, expectedTotal: 4},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for engine, target := range map[string]string{"ent": `"entity": "test3schema"`, "dynamic": `"table": "conformance"`} {
+				rec := httptest.NewRecorder()
+				body := fmt.Sprintf(`{%s, %s}`, target, tc.options)
+				distinctValuesHandler(rec, httptest.NewRequest(http.MethodPost, "/filter/distinct", strings.NewReader(body)))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("%s: expected status 200, got %d: %s", engine, rec.Code, rec.Body.String())
+				}
+				var result struct {
+					Data       []interface{} `json:"data"`
+					TotalCount int           `json:"totalCount"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+					t.Fatalf("%s: response is not a load result: %v: %s", engine, err, rec.Body.String())
+				}
+				if got := summarizeGroups(result.Data); got != tc.expectedValues || result.TotalCount != tc.expectedTotal {
+					t.Errorf("%s: expected %s with total %d, got %s with total %d", engine, tc.expectedValues, tc.expectedTotal, got, result.TotalCount)
+				}
+			}
+		})
+	}
+
+	for _, body := range []string{
+		`{"entity": "test3schema", "field": "stock_count", "searchValue": "1"}`,
+		`{"entity": "test3schema", "field": "nosuchfield"}`,
+		`{"table": "conformance", "field": "is_active", "groupInterval": 2}`,
+	} {
+		rec := httptest.NewRecorder()
+		distinctValuesHandler(rec, httptest.NewRequest(http.MethodPost, "/filter/distinct", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+// conformanceEndpoints returns functions sending the given load options to
+// /filter for Test3Schema and to the conformance table's filter endpoint.
+func conformanceEndpoints(options string) map[string]func(*httptest.ResponseRecorder) {
+	return map[string]func(*httptest.ResponseRecorder){
+		"ent": func(rec *httptest.ResponseRecorder) {
+			body := fmt.Sprintf(`{"entity": "test3schema", %s}`, options)
+			filterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(body)))
+		},
+		"dynamic": func(rec *httptest.ResponseRecorder) {
+			body := fmt.Sprintf(`{%s}`, options)
+			dynamicTableFilterHandler(rec, httptest.NewRequest(http.MethodPost, "/dynamic-tables/conformance/filter", strings.NewReader(body)), "conformance")
+		},
+	}
+}
+
+// summarizeGroups renders decoded groups as "key:count", followed by their
+// subgroups in braces or the skus of their records in brackets.
+func summarizeGroups(items []interface{}) string {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		group := item.(map[string]interface{})
+		part := fmt.Sprintf("%v:%v", group["key"], group["count"])
+		if children, ok := group["items"].([]interface{}); ok {
+			if len(children) > 0 {
+				if _, isRecord := children[0].(map[string]interface{})["sku"]; isRecord {
+					skus := make([]string, len(children))
+					for j, child := range children {
+						skus[j] = child.(map[string]interface{})["sku"].(string)
+					}
+					part += fmt.Sprint(skus)
+					parts[i] = part
+					continue
+				}
+			}
+			part += "{" + summarizeGroups(children) + "}"
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, " ")
+}
+
+func insertConformanceRows(ctx context.Context) {
+	for _, row := range conformanceRows {
+		testClient.Test3Schema.Create().SetSku(row.sku).SetProductName(row.productName).
+			SetCostPrice(row.costPrice).SetRetailPrice(row.retailPrice).SetStockCount(row.stockCount).SetIsActive(row.isActive).
+			SetNillableShortDescription(row.shortDesc).SetNillableTags(row.tags).
+			SetNillablePublishedAt(row.publishedAt).SetNillableLastOrderedAt(row.lastOrdered).
+			SaveX(ctx)
+	}
+}
+
+// insertRawConformanceRows stores conformanceRawRows as Test3Schema rows,
+// writing their values with plain SQL so that they keep their type.
+func insertRawConformanceRows(ctx context.Context, t *testing.T) {
+	t.Helper()
+	for _, row := range conformanceRawRows {
+		testClient.Test3Schema.Create().SetSku(row["sku"].(string)).SaveX(ctx)
+		update := sql.Dialect(dialect.SQLite).Update(test3schema.Table).Where(sql.EQ(test3schema.FieldSku, row["sku"]))
+		for column, value := range row {
+			update.Set(column, value)
+		}
+		query, args := update.Query()
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			t.Fatalf("storing raw row %v: %v", row["sku"], err)
+		}
+	}
+}
+
+// conformanceTable writes conformanceRows, followed by any extra records,
+// as a file-based table and loads it back.
+func conformanceTable(t *testing.T, extra ...map[string]interface{}) (*dynamictablefilter.TableSchema, []map[string]interface{}) {
+	t.Helper()
+	dir := writeConformanceTable(t, extra...)
+	originalPath := dynamictablefilter.GetBaseTablesPath()
+	dynamictablefilter.SetBaseTablesPath(filepath.Dir(dir))
+	defer dynamictablefilter.SetBaseTablesPath(originalPath)
+	schema, err := dynamictablefilter.LoadTableSchema("conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := dynamictablefilter.LoadTableData("conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema, data
+}
+
+// writeConformanceTable writes conformanceRows and any extra records with
+// the Test3Schema schema definition as a file-based table named
+// "conformance" and returns its directory.
+func writeConformanceTable(t *testing.T, extra ...map[string]interface{}) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "conformance")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	schemaJSON, err := os.ReadFile("./schema_definitions/test3schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := make([]map[string]interface{}, len(conformanceRows))
+	for i, row := range conformanceRows {
+		record := map[string]interface{}{
+			"sku": row.sku, "product_name": row.productName, "cost_price": row.costPrice,
+			"retail_price": row.retailPrice, "stock_count": row.stockCount, "is_active": row.isActive,
+		}
+		if row.shortDesc != nil {
+			record["short_description"] = *row.shortDesc
+		}
+		if row.tags != nil {
+			record["tags"] = *row.tags
+		}
+		if row.publishedAt != nil {
+			record["published_at"] = row.publishedAt.Format(time.RFC3339)
+		}
+		if row.lastOrdered != nil {
+			record["last_ordered_at"] = row.lastOrdered.Format(time.RFC3339)
+		}
+		records[i] = record
+	}
+	records = append(records, extra...)
+	dataJSON, err := json.Marshal(records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "schema.json"), schemaJSON, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "data.json"), dataJSON, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
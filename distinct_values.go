@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"transaction-filter-backend/dynamictablefilter"
+	"transaction-filter-backend/filterast"
+	"transaction-filter-backend/schematool"
+)
+
+// distinctValuesRequest asks for the distinct values of one field of an ent
+// entity ("entity") or dynamic table ("table") among the records matching
+// the filter, as DataGrid header filters and FilterBuilder lookups list them.
+type distinctValuesRequest struct {
+	filterTargetRequest
+	Field string `json:"field"`
+	// SearchValue keeps the values of a string field containing it, under
+	// the field's collation.
+	SearchValue   string      `json:"searchValue"`
+	GroupInterval interface{} `json:"groupInterval"`
+	Desc          bool        `json:"desc"`
+	Skip          int         `json:"skip"`
+	Take          int         `json:"take"`
+	Collation     string      `json:"collation"`
+}
+
+// dateHeaderIntervals are the levels of a time.Time field's values, outermost
+// first, as DevExtreme's date header filter shows them. A groupInterval of
+// "month" stops after the month level.
+var dateHeaderIntervals = []string{"year", "month", "day"}
+
+// distinctValuesHandler answers POST /filter/distinct with the distinct
+// values of a field and the number of records holding each, as DevExtreme
+// {key, items, count} groups: plain values, buckets of a numeric
+// groupInterval, or for time.Time fields a year, month and day tree.
+// totalCount is the number of distinct (top-level) values before paging.
+func distinctValuesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var requestBody distinctValuesRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		log.Printf("Backend: Error decoding request body for %s: %v", r.URL.Path, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if requestBody.Skip < 0 || requestBody.Take < 0 {
+		http.Error(w, "skip and take must not be negative", http.StatusBadRequest)
+		return
+	}
+	fields, ok := resolveFilterTarget(w, requestBody.filterTargetRequest)
+	if !ok {
+		return
+	}
+	groups, err := distinctGroups(fields, requestBody.Field, requestBody.GroupInterval, requestBody.Desc)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	filter := requestBody.Filter
+	if requestBody.SearchValue != "" {
+		if !filterast.IsStringType(groups[0].Type()) {
+			http.Error(w, fmt.Sprintf("searchValue requires a string field, '%s' is %s", requestBody.Field, groups[0].Type()), http.StatusBadRequest)
+			return
+		}
+		search := []interface{}{requestBody.Field, "contains", requestBody.SearchValue}
+		if filter == nil {
+			filter = search
+		} else {
+			filter = []interface{}{filter, "and", search}
+		}
+	}
+	root, err := filterast.Parse(filter, fields)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	if err := filterast.SetCollation(root, requestBody.Collation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filterast.SetGroupCollation(groups, requestBody.Collation)
+
+	var counts []filterast.GroupCount
+	if requestBody.Entity != "" {
+		counts, err = countDistinctEntityValues(context.Background(), requestBody.Entity, root, groups)
+	} else {
+		counts, err = countDistinctTableValues(requestBody.Table, root, groups)
+	}
+	if err != nil {
+		log.Printf("Backend: Error listing values of %s: %v", requestBody.Field, err)
+		http.Error(w, fmt.Sprintf("Error listing values: %v", err), http.StatusInternalServerError)
+		return
+	}
+	// The last level is collapsed, so no records are fetched.
+	page, valueCount, _ := groupPage(counts, groups, requestBody.Skip, requestBody.Take, nil)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loadResult{Data: page, TotalCount: valueCount})
+}
+
+// distinctGroups returns the group levels listing the values of field, the
+// last of them collapsed.
+func distinctGroups(fields map[string]schematool.SchemaFieldDefinition, field string, interval interface{}, desc bool) ([]filterast.GroupField, error) {
+	level := func(interval interface{}) interface{} {
+		return map[string]interface{}{"selector": field, "groupInterval": interval, "desc": desc}
+	}
+	groups, err := filterast.ParseGroup([]interface{}{field}, fields)
+	if err != nil {
+		return nil, err
+	}
+	levels := []interface{}{level(interval)}
+	if groups[0].Type() == "time.Time" {
+		depth := 0
+		if interval == nil {
+			depth = len(dateHeaderIntervals)
+		}
+		for i, name := range dateHeaderIntervals {
+			if s, isStr := interval.(string); isStr && strings.EqualFold(s, name) {
+				depth = i + 1
+			}
+		}
+		if depth > 0 {
+			levels = levels[:0]
+			for _, name := range dateHeaderIntervals[:depth] {
+				levels = append(levels, level(name))
+			}
+		}
+	}
+	if groups, err = filterast.ParseGroup(levels, fields); err != nil {
+		return nil, err
+	}
+	groups[len(groups)-1].IsExpanded = false
+	return groups, nil
+}
+
+// countDistinctEntityValues counts an ent entity's rows matching root for
+// each combination of group keys, with one GROUP BY query.
+func countDistinctEntityValues(ctx context.Context, entity string, root filterast.Node, groups []filterast.GroupField) ([]filterast.GroupCount, error) {
+	adapter, err := GetAdapter(entity)
+	if err != nil {
+		return nil, err
+	}
+	pred, err := BuildPredicate(adapter, root)
+	if err != nil {
+		return nil, err
+	}
+	return countEntityGroups(ctx, entity, adapter, pred, groups, nil)
+}
+
+// countDistinctTableValues counts a dynamic table's records matching root
+// for each combination of group keys.
+func countDistinctTableValues(table string, root filterast.Node, groups []filterast.GroupField) ([]filterast.GroupCount, error) {
+	schema, err := dynamictablefilter.LoadTableSchema(table)
+	if err != nil {
+		return nil, err
+	}
+	data, err := dynamictablefilter.LoadTableData(table)
+	if err != nil {
+		return nil, err
+	}
+	return dynamictablefilter.GroupDynamicData(dynamictablefilter.MatchDynamicData(data, schema, root), groups, nil), nil
+}
@@ -6,9 +6,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 	"time"
+	"transaction-filter-backend/fieldexpr"
+	"transaction-filter-backend/filterast"
 	"transaction-filter-backend/schematool" // For SchemaRequest, SchemaFieldDefinition
 )
 
@@ -28,6 +30,91 @@ type TableSchema struct {
 	EntityName string                                      `json:"entityName"`
 	Fields     []schematool.SchemaFieldDefinition          `json:"fields"`
 	FieldMap   map[string]schematool.SchemaFieldDefinition // Exported
+	// Computed holds the compiled expression of each computed field, keyed by
+	// lower-cased name. Computed fields are also listed in Fields and FieldMap.
+	Computed map[string]*fieldexpr.Expr `json:"-"`
+}
+
+// Index builds FieldMap from Fields and compiles the expressions of computed
+// fields. Expressions may only read stored fields. A computed field without
+// a type gets the expression's type; a declared type must be able to hold
+// it. Collations are checked too, and only allowed on string fields. Allowed
+// values are coerced to the field's type; computed fields cannot have them.
+func (s *TableSchema) Index() error {
+	stored := make(map[string]schematool.SchemaFieldDefinition)
+	for _, field := range s.Fields {
+		if field.Expression == "" {
+			stored[strings.ToLower(field.Name)] = field
+		}
+	}
+	s.FieldMap = make(map[string]schematool.SchemaFieldDefinition)
+	s.Computed = make(map[string]*fieldexpr.Expr)
+	for i, field := range s.Fields {
+		key := strings.ToLower(field.Name)
+		if field.Expression != "" {
+			expr, err := fieldexpr.Parse(field.Expression, stored)
+			if err != nil {
+				return fmt.Errorf("computed field '%s': %w", field.Name, err)
+			}
+			switch {
+			case field.Type == "":
+				s.Fields[i].Type = expr.Type
+			case field.Type == expr.Type,
+				field.Type == "float64" && expr.Type == "int",
+				field.Type == "text" && expr.Type == "string":
+			default:
+				return fmt.Errorf("computed field '%s' is declared %s but its expression yields %s", field.Name, field.Type, expr.Type)
+			}
+			s.Computed[key] = expr
+		}
+		if field.Collation != "" {
+			if !filterast.IsStringType(s.Fields[i].Type) {
+				return fmt.Errorf("field '%s': a collation requires a string field", field.Name)
+			}
+			if !filterast.ValidCollation(field.Collation) {
+				return fmt.Errorf("field '%s': unknown collation '%s'", field.Name, field.Collation)
+			}
+		}
+		if field.Expression != "" && (field.Required || len(field.Values) > 0) {
+			return fmt.Errorf("computed field '%s' cannot be required or restricted to values", field.Name)
+		}
+		if len(field.Values) > 0 {
+			values := make([]interface{}, len(field.Values))
+			for j, v := range field.Values {
+				coerced, err := filterast.CoerceValue(field.Type, v)
+				if err != nil {
+					return fmt.Errorf("field '%s': value %v: %w", field.Name, v, err)
+				}
+				values[j] = coerced
+			}
+			s.Fields[i].Values = values
+		}
+		s.FieldMap[key] = s.Fields[i]
+	}
+	return nil
+}
+
+// WithComputed returns records with the value of every computed field added;
+// the input records are not modified. Without computed fields it returns
+// records as is.
+func (s *TableSchema) WithComputed(records []map[string]interface{}) []map[string]interface{} {
+	if len(s.Computed) == 0 {
+		return records
+	}
+	result := make([]map[string]interface{}, len(records))
+	for i, record := range records {
+		extended := make(map[string]interface{}, len(record)+len(s.Computed))
+		for k, v := range record {
+			extended[k] = v
+		}
+		for _, field := range s.Fields {
+			if expr, ok := s.Computed[strings.ToLower(field.Name)]; ok {
+				extended[field.Name] = expr.Eval(record)
+			}
+		}
+		result[i] = extended
+	}
+	return result
 }
 
 func LoadTableSchema(tableName string) (*TableSchema, error) {
@@ -40,9 +127,8 @@ func LoadTableSchema(tableName string) (*TableSchema, error) {
 	if err := json.Unmarshal(data, &schema); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal schema for %s: %w", tableName, err)
 	}
-	schema.FieldMap = make(map[string]schematool.SchemaFieldDefinition) // Use exported
-	for _, field := range schema.Fields {
-		schema.FieldMap[strings.ToLower(field.Name)] = field
+	if err := schema.Index(); err != nil {
+		return nil, fmt.Errorf("invalid schema for %s: %w", tableName, err)
 	}
 	return &schema, nil
 }
@@ -80,223 +166,419 @@ func ListDynamicTables() ([]string, error) {
 	return tableNames, nil
 }
 
-func evaluateCondition(recordVal interface{}, op string, filterVal interface{}, fieldType string) bool {
-	op = strings.ToLower(op)
-	switch fieldType {
-	case "string":
-		sRecordVal := fmt.Sprintf("%v", recordVal)
-		sFilterVal := fmt.Sprintf("%v", filterVal)
-		switch op {
-		case "=":
-			return strings.EqualFold(sRecordVal, sFilterVal)
-		case "<>":
-			return !strings.EqualFold(sRecordVal, sFilterVal)
-		case "contains":
-			return strings.Contains(strings.ToLower(sRecordVal), strings.ToLower(sFilterVal))
-		case "startswith":
-			return strings.HasPrefix(strings.ToLower(sRecordVal), strings.ToLower(sFilterVal))
-		case "endswith":
-			return strings.HasSuffix(strings.ToLower(sRecordVal), strings.ToLower(sFilterVal))
-		case "notcontains":
-			return !strings.Contains(strings.ToLower(sRecordVal), strings.ToLower(sFilterVal))
-		}
-	case "int":
-		iRecordVal, okR := recordVal.(float64)
-		if !okR {
-			if rv, okInt := recordVal.(int); okInt {
-				iRecordVal = float64(rv)
-			} else {
-				return false
-			}
-		}
-		iFilterVal, errF := strconv.ParseFloat(fmt.Sprintf("%v", filterVal), 64)
-		if errF != nil {
-			return false
-		}
-		switch op {
-		case "=":
-			return int(iRecordVal) == int(iFilterVal)
-		case "<>":
-			return int(iRecordVal) != int(iFilterVal)
-		case ">":
-			return int(iRecordVal) > int(iFilterVal)
-		case ">=":
-			return int(iRecordVal) >= int(iFilterVal)
-		case "<":
-			return int(iRecordVal) < int(iFilterVal)
-		case "<=":
-			return int(iRecordVal) <= int(iFilterVal)
-		}
-	case "float64":
-		fRecordVal, okR := recordVal.(float64)
-		if !okR {
-			return false
-		}
-		fFilterVal, errF := strconv.ParseFloat(fmt.Sprintf("%v", filterVal), 64)
-		if errF != nil {
-			return false
-		}
-		switch op {
-		case "=":
-			return fRecordVal == fFilterVal
-		case "<>":
-			return fRecordVal != fFilterVal
-		case ">":
-			return fRecordVal > fFilterVal
-		case ">=":
-			return fRecordVal >= fFilterVal
-		case "<":
-			return fRecordVal < fFilterVal
-		case "<=":
-			return fRecordVal <= fFilterVal
-		}
-	case "bool":
-		bRecordVal, okR := recordVal.(bool)
-		if !okR {
-			return false
-		}
-		bFilterVal, errF := strconv.ParseBool(strings.ToLower(fmt.Sprintf("%v", filterVal)))
-		if errF != nil {
-			return false
-		}
-		switch op {
-		case "=":
-			return bRecordVal == bFilterVal
-		case "<>":
-			return bRecordVal != bFilterVal
+// collate returns the key a string value is compared under for collation,
+// see filterast.Fold; other values are returned as is.
+func collate(v interface{}, collation string) interface{} {
+	if s, ok := v.(string); ok && collation != "" {
+		return filterast.Fold(s, collation)
+	}
+	return v
+}
+
+// compareCollated orders two values of the same field type, returning -1,
+// 0 or 1: strings by their collation keys, and an int against a float64
+// numerically.
+func compareCollated(a, b interface{}, collation string) int {
+	a, b = collate(a, collation), collate(b, collation)
+	if ai, ok := a.(int); ok {
+		if _, ok := b.(float64); ok {
+			a = float64(ai)
 		}
-	case "time.Time":
-		sRecordVal := fmt.Sprintf("%v", recordVal)
-		sFilterVal := fmt.Sprintf("%v", filterVal)
-		layouts := []string{time.RFC3339Nano, "2006-01-02T15:04:05Z", "2006-01-02T15:04:05", "2006-01-02"}
-		var tRecordVal, tFilterVal time.Time
-		var errR, errF error
-		for _, layout := range layouts {
-			if t, err := time.Parse(layout, sRecordVal); err == nil {
-				tRecordVal = t
-				errR = nil
-				break
-			} else {
-				errR = err
-			}
+	}
+	if bi, ok := b.(int); ok {
+		if _, ok := a.(float64); ok {
+			b = float64(bi)
 		}
-		if errR != nil {
-			return false
+	}
+	return filterast.CompareValues(a, b)
+}
+
+// truth is the result of evaluating a filter on a record under SQL's
+// three-valued logic: comparing a null is unknown, NOT leaves unknown
+// unknown, and a record matches only when the filter is true. This is what
+// keeps ["!", ["name", "=", "x"]] from matching a null name, as in SQLite.
+type truth int8
+
+const (
+	truthFalse truth = iota
+	truthTrue
+	truthUnknown
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+// fieldValue returns the Go value of a field, or one of its date parts, in
+// record; ok is false when the value is null, missing or malformed.
+func fieldValue(record map[string]interface{}, field schematool.SchemaFieldDefinition, part string) (interface{}, bool) {
+	raw := record[field.Name]
+	if raw == nil {
+		return nil, false
+	}
+	v, ok := filterast.RecordValue(field.Type, raw)
+	if !ok {
+		return nil, false
+	}
+	if part != "" {
+		return filterast.DatePart(v.(time.Time), part), true
+	}
+	return v, true
+}
+
+// evaluateFieldComparison compares two fields of one record. Like SQL, a
+// null on either side is unknown. int and float64 fields compare
+// numerically, and strings under the condition's collation.
+func evaluateFieldComparison(record map[string]interface{}, cond *filterast.Condition) truth {
+	left, ok := fieldValue(record, cond.Field, cond.Part)
+	if !ok {
+		return truthUnknown
+	}
+	right, ok := fieldValue(record, cond.ValueField.Field, cond.ValueField.Part)
+	if !ok {
+		return truthUnknown
+	}
+	return truthOf(compareResult(cond.Operator, compareCollated(left, right, cond.Collation)))
+}
+
+func evaluateCondition(recordVal interface{}, cond *filterast.Condition) truth {
+	fieldType := cond.Field.Type
+	// A missing key or JSON null is treated like SQL NULL: it only satisfies
+	// null checks and isblank, and any comparison with a value is unknown.
+	// Malformed values are treated the same way.
+	switch cond.Operator {
+	case "isblank":
+		return truthOf(isBlankValue(recordVal, fieldType))
+	case "isnotblank":
+		return truthOf(!isBlankValue(recordVal, fieldType))
+	case "=", "<>":
+		if cond.Value == nil && cond.ValueField == nil {
+			return truthOf((recordVal == nil) == (cond.Operator == "="))
 		}
-		for _, layout := range layouts {
-			if t, err := time.Parse(layout, sFilterVal); err == nil {
-				tFilterVal = t
-				errF = nil
+	}
+	if recordVal == nil {
+		return truthUnknown
+	}
+	rv, ok := filterast.RecordValue(fieldType, recordVal)
+	if !ok {
+		return truthUnknown
+	}
+	if cond.Part != "" {
+		rv = filterast.DatePart(rv.(time.Time), cond.Part)
+	}
+	return truthOf(compareCondition(rv, cond))
+}
+
+// compareCondition applies a condition to a non-null record value of the
+// condition's type.
+func compareCondition(rv interface{}, cond *filterast.Condition) bool {
+	switch cond.Operator {
+	case "anyof", "noneof":
+		// Header filters send the selected values as an array; anyof matches
+		// when the record equals any of them, noneof when it equals none.
+		matched := false
+		for _, v := range cond.Values {
+			if compareCollated(rv, v, cond.Collation) == 0 {
+				matched = true
 				break
-			} else {
-				errF = err
 			}
 		}
-		if errF != nil {
-			return false
-		}
-		switch op {
-		case "=":
-			return tRecordVal.Equal(tFilterVal)
-		case "<>":
-			return !tRecordVal.Equal(tFilterVal)
-		case ">":
-			return tRecordVal.After(tFilterVal)
-		case ">=":
-			return tRecordVal.After(tFilterVal) || tRecordVal.Equal(tFilterVal)
-		case "<":
-			return tRecordVal.Before(tFilterVal)
-		case "<=":
-			return tRecordVal.Before(tFilterVal) || tRecordVal.Equal(tFilterVal)
+		return matched == (cond.Operator == "anyof")
+	case "between":
+		return compareCollated(rv, cond.Values[0], cond.Collation) >= 0 && compareCollated(rv, cond.Values[1], cond.Collation) <= 0
+	case "matches":
+		// Regular expressions see the value as stored, whatever the collation.
+		re, err := filterast.CompilePattern(cond.Value.(string))
+		return err == nil && re.MatchString(rv.(string))
+	case "like":
+		re, err := filterast.CompilePattern(filterast.LikePattern(filterast.Fold(cond.Value.(string), cond.Collation)))
+		return err == nil && re.MatchString(filterast.Fold(rv.(string), cond.Collation))
+	case "fuzzy":
+		fuzzy := cond.Value.(filterast.FuzzyValue)
+		return filterast.FuzzyDistance(rv.(string), fuzzy.Value, cond.Collation) <= fuzzy.Distance
+	case "contains", "notcontains", "startswith", "endswith":
+		sRecordVal := filterast.Fold(rv.(string), cond.Collation)
+		sFilterVal := filterast.Fold(cond.Value.(string), cond.Collation)
+		switch cond.Operator {
+		case "contains":
+			return strings.Contains(sRecordVal, sFilterVal)
+		case "notcontains":
+			return !strings.Contains(sRecordVal, sFilterVal)
+		case "startswith":
+			return strings.HasPrefix(sRecordVal, sFilterVal)
+		default:
+			return strings.HasSuffix(sRecordVal, sFilterVal)
 		}
 	}
+
+	return compareResult(cond.Operator, compareCollated(rv, cond.Value, cond.Collation))
+}
+
+// compareResult applies a comparison operator to the result of
+// compareCollated.
+func compareResult(op string, c int) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "<>":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
 	return false
 }
 
-func applyFilterRecursive(record map[string]interface{}, schema *TableSchema, filterGroup []interface{}) (bool, error) {
-	if len(filterGroup) == 0 {
-		return true, nil
+// isBlankValue reports whether a record value is null, or an empty string for
+// string fields.
+func isBlankValue(recordVal interface{}, fieldType string) bool {
+	if recordVal == nil {
+		return true
 	}
-	if s, ok := filterGroup[0].(string); ok && s == "!" {
-		if len(filterGroup) != 2 {
-			return false, fmt.Errorf("malformed NOT filter: expected 2 elements, got %d", len(filterGroup))
-		}
-		subFilterGroup, okCast := filterGroup[1].([]interface{})
-		if !okCast {
-			return false, fmt.Errorf("NOT filter operand must be an array, got %T", filterGroup[1])
+	if filterast.IsStringType(fieldType) {
+		s, ok := recordVal.(string)
+		return ok && s == ""
+	}
+	return false
+}
+
+// matchNode evaluates a parsed filter tree against one record. A nil node
+// matches every record.
+func matchNode(record map[string]interface{}, node filterast.Node) truth {
+	switch n := node.(type) {
+	case *filterast.Condition:
+		if expanded, ok := filterast.ExpandWholeDay(n); ok {
+			return matchNode(record, expanded)
 		}
-		subMatch, err := applyFilterRecursive(record, schema, subFilterGroup)
-		if err != nil {
-			return false, err
+		if n.ValueField != nil {
+			return evaluateFieldComparison(record, n)
 		}
-		return !subMatch, nil
-	}
-	if _, ok := filterGroup[0].(string); ok && len(filterGroup) == 3 {
-		fieldName, _ := filterGroup[0].(string)
-		operator, _ := filterGroup[1].(string)
-		value := filterGroup[2]
-		fieldSchema, fieldExists := schema.FieldMap[strings.ToLower(fieldName)] // Use exported
-		if !fieldExists {
-			return false, fmt.Errorf("field '%s' not found in schema for dynamic table", fieldName)
+		return evaluateCondition(record[n.Field.Name], n) // nil when the key is missing
+	case *filterast.Not:
+		switch matchNode(record, n.Child) {
+		case truthTrue:
+			return truthFalse
+		case truthFalse:
+			return truthTrue
 		}
-		recordVal, recordValExists := record[fieldName]
-		if !recordValExists {
-			return false, nil
+		return truthUnknown
+	case *filterast.Group:
+		// AND is false as soon as a child is false and OR true as soon as a
+		// child is true; otherwise an unknown child makes the group unknown.
+		decisive := truthOf(n.Op == filterast.Or)
+		result := truthOf(n.Op == filterast.And)
+		for _, child := range n.Children {
+			switch matchNode(record, child) {
+			case decisive:
+				return decisive
+			case truthUnknown:
+				result = truthUnknown
+			}
 		}
-		return evaluateCondition(recordVal, operator, value, fieldSchema.Type), nil
+		return result
 	}
-	currentMatch, err := applyFilterRecursive(record, schema, filterGroup[0].([]interface{}))
+	return truthTrue
+}
+
+// FilterDynamicData returns the records matching filterInput, with the
+// values of the schema's computed fields added to each of them.
+func FilterDynamicData(data []map[string]interface{}, schema *TableSchema, filterInput interface{}) ([]map[string]interface{}, error) {
+	root, err := filterast.Parse(filterInput, schema.FieldMap)
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("invalid filter for dynamic table: %w", err)
+	}
+	return MatchDynamicData(data, schema, root), nil
+}
+
+// MatchDynamicData is FilterDynamicData for a filter already parsed against
+// the schema, e.g. one whose collation a request overrides.
+func MatchDynamicData(data []map[string]interface{}, schema *TableSchema, root filterast.Node) []map[string]interface{} {
+	data = schema.WithComputed(data)
+	if root == nil {
+		return data
 	}
-	for i := 1; i < len(filterGroup); i += 2 {
-		if i+1 >= len(filterGroup) {
-			return false, fmt.Errorf("malformed group filter: missing condition after operator")
+	var filteredResults []map[string]interface{}
+	for _, record := range data {
+		if matchNode(record, root) == truthTrue {
+			filteredResults = append(filteredResults, record)
 		}
-		logicalOperatorStr, ok := filterGroup[i].(string)
-		if !ok {
-			return false, fmt.Errorf("logical operator must be a string, got %T", filterGroup[i])
+	}
+	return filteredResults
+}
+
+// SortDynamicData orders records in place by the given criteria. Like
+// SQLite, null and missing values sort before any value in ascending order.
+func SortDynamicData(records []map[string]interface{}, sorts []filterast.SortField) {
+	if len(sorts) == 0 {
+		return
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, s := range sorts {
+			a, okA := fieldValue(records[i], s.Field, s.Part)
+			b, okB := fieldValue(records[j], s.Field, s.Part)
+			var c int
+			switch {
+			case !okA && !okB:
+				continue
+			case !okA:
+				c = -1
+			case !okB:
+				c = 1
+			default:
+				c = compareCollated(a, b, s.Collation)
+			}
+			if c == 0 {
+				continue
+			}
+			if s.Desc {
+				return c > 0
+			}
+			return c < 0
 		}
-		logicalOperator := strings.ToLower(logicalOperatorStr)
-		subFilterGroup, okCast := filterGroup[i+1].([]interface{})
-		if !okCast {
-			return false, fmt.Errorf("group filter operand must be an array, got %T", filterGroup[i+1])
+		return false
+	})
+}
+
+// GroupDynamicData orders records in place by their group keys, keeping
+// the existing order within each group, and counts the records of each
+// combination of keys, in that order, aggregating the given summaries over
+// them. Strings are ordered by their collation key and then as stored, like
+// for ent entities. Without groups all records share one combination.
+func GroupDynamicData(records []map[string]interface{}, groups []filterast.GroupField, summaries []filterast.SummaryItem) []filterast.GroupCount {
+	keys := make([][]interface{}, len(records))
+	for i, record := range records {
+		keys[i] = make([]interface{}, len(groups))
+		for level, g := range groups {
+			keys[i][level] = groupKey(record, g)
 		}
-		nextSubMatch, err := applyFilterRecursive(record, schema, subFilterGroup)
-		if err != nil {
-			return false, err
+	}
+	sort.Stable(byGroupKeys{records, keys, groups})
+	var counts []filterast.GroupCount
+	for i, record := range records {
+		n := len(counts)
+		if n == 0 || compareGroupKeys(counts[n-1].Keys, keys[i], groups) != 0 {
+			counts = append(counts, filterast.GroupCount{Keys: keys[i], Aggregates: filterast.NewAggregates(summaries)})
+			n++
 		}
-		if logicalOperator == "and" {
-			currentMatch = currentMatch && nextSubMatch
-		} else if logicalOperator == "or" {
-			currentMatch = currentMatch || nextSubMatch
-		} else {
-			return false, fmt.Errorf("invalid logical operator: '%s'", logicalOperatorStr)
+		counts[n-1].Count++
+		for j, s := range summaries {
+			var v interface{}
+			if s.Field.Name != "" {
+				v, _ = fieldValue(record, s.Field, s.Part)
+			}
+			if t, isTime := v.(time.Time); isTime {
+				v = t.UTC()
+			}
+			counts[n-1].Aggregates[j].Add(v)
 		}
 	}
-	return currentMatch, nil
+	return counts
 }
 
-func FilterDynamicData(data []map[string]interface{}, schema *TableSchema, filterInput interface{}) ([]map[string]interface{}, error) {
-	if filterInput == nil {
-		return data, nil
-	}
-	filterArray, ok := filterInput.([]interface{})
+// groupKey returns a record's key at one group level, nil for a null value.
+func groupKey(record map[string]interface{}, g filterast.GroupField) interface{} {
+	v, ok := fieldValue(record, g.Field, g.Part)
 	if !ok {
-		return nil, fmt.Errorf("filter input is not an array, got %T", filterInput)
+		return nil
 	}
-	if len(filterArray) == 0 {
-		return data, nil
+	switch value := v.(type) {
+	case time.Time:
+		return value.UTC()
+	case int:
+		if g.Interval > 0 {
+			return filterast.Bucket(float64(value), g.Interval)
+		}
+	case float64:
+		if g.Interval > 0 {
+			return filterast.Bucket(value, g.Interval)
+		}
 	}
-	var filteredResults []map[string]interface{}
-	for _, record := range data {
-		match, err := applyFilterRecursive(record, schema, filterArray)
-		if err != nil {
-			return nil, fmt.Errorf("error evaluating filter for a record: %w", err)
+	return v
+}
+
+// compareGroupKeys orders two records' group keys level by level, nulls
+// first in ascending order like SQLite.
+func compareGroupKeys(a, b []interface{}, groups []filterast.GroupField) int {
+	for level, g := range groups {
+		var c int
+		switch {
+		case a[level] == nil && b[level] == nil:
+		case a[level] == nil:
+			c = -1
+		case b[level] == nil:
+			c = 1
+		default:
+			c = compareCollated(a[level], b[level], g.Collation)
+			if c == 0 && g.Collation != "" {
+				c = compareCollated(a[level], b[level], "")
+			}
 		}
-		if match {
-			filteredResults = append(filteredResults, record)
+		if g.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
 		}
 	}
-	return filteredResults, nil
+	return 0
+}
+
+// byGroupKeys sorts records together with their precomputed group keys.
+type byGroupKeys struct {
+	records []map[string]interface{}
+	keys    [][]interface{}
+	groups  []filterast.GroupField
+}
+
+func (g byGroupKeys) Len() int { return len(g.records) }
+func (g byGroupKeys) Less(i, j int) bool {
+	return compareGroupKeys(g.keys[i], g.keys[j], g.groups) < 0
+}
+func (g byGroupKeys) Swap(i, j int) {
+	g.records[i], g.records[j] = g.records[j], g.records[i]
+	g.keys[i], g.keys[j] = g.keys[j], g.keys[i]
+}
+
+// RankByFuzzyDistance orders records in place by their smallest distance to
+// the given "fuzzy" conditions, closest first, keeping the existing order
+// between records at the same distance. Null fields rank last.
+func RankByFuzzyDistance(records []map[string]interface{}, conds []*filterast.Condition) {
+	if len(conds) == 0 {
+		return
+	}
+	rank := func(record map[string]interface{}) int {
+		best := filterast.NoFuzzyMatch
+		for _, cond := range conds {
+			if v, ok := fieldValue(record, cond.Field, ""); ok {
+				best = min(best, filterast.FuzzyDistance(v.(string), cond.Value.(filterast.FuzzyValue).Value, cond.Collation))
+			}
+		}
+		return best
+	}
+	keys := make([]int, len(records))
+	for i, record := range records {
+		keys[i] = rank(record)
+	}
+	sort.Stable(byRank{records, keys})
+}
+
+// byRank sorts records together with their precomputed rank keys.
+type byRank struct {
+	records []map[string]interface{}
+	keys    []int
+}
+
+func (r byRank) Len() int           { return len(r.records) }
+func (r byRank) Less(i, j int) bool { return r.keys[i] < r.keys[j] }
+func (r byRank) Swap(i, j int) {
+	r.records[i], r.records[j] = r.records[j], r.records[i]
+	r.keys[i], r.keys[j] = r.keys[j], r.keys[i]
 }
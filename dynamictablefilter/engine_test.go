@@ -0,0 +1,494 @@
+package dynamictablefilter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"transaction-filter-backend/filterast"
+	"transaction-filter-backend/schematool"
+)
+
+func newTestSchema(fields ...schematool.SchemaFieldDefinition) *TableSchema {
+	schema := &TableSchema{EntityName: "test", Fields: fields, FieldMap: make(map[string]schematool.SchemaFieldDefinition)}
+	for _, field := range fields {
+		schema.FieldMap[strings.ToLower(field.Name)] = field
+	}
+	return schema
+}
+
+func testRecords() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"id": float64(1), "name": "Alpha", "qty": float64(10), "active": true},
+		{"id": float64(2), "name": "Beta", "qty": float64(20), "active": false},
+		{"id": float64(3), "name": "Gamma", "qty": float64(30), "active": true},
+		{"id": float64(4), "name": "Delta", "qty": float64(40), "active": false},
+	}
+}
+
+func matchedIDs(records []map[string]interface{}) []int {
+	ids := make([]int, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, int(r["id"].(float64)))
+	}
+	return ids
+}
+
+func TestFilterDynamicDataGroups(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "name", Type: "string"},
+		schematool.SchemaFieldDefinition{Name: "qty", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "active", Type: "bool"},
+	)
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		expectedIDs []int
+		expectError bool
+	}{
+		{
+			name: "AND binds tighter than OR",
+			filter: []interface{}{
+				[]interface{}{"id", "=", 1},
+				"or",
+				[]interface{}{"qty", ">", 15},
+				"and",
+				[]interface{}{"active", "=", false},
+			},
+			expectedIDs: []int{1, 2, 4},
+		},
+		{
+			name: "AND run before OR",
+			filter: []interface{}{
+				[]interface{}{"qty", ">", 15},
+				"and",
+				[]interface{}{"active", "=", true},
+				"or",
+				[]interface{}{"name", "=", "Alpha"},
+			},
+			expectedIDs: []int{1, 3},
+		},
+		{
+			name:        "Implicit AND with equality shorthand",
+			filter:      []interface{}{[]interface{}{"active", true}, []interface{}{"qty", ">", 15}},
+			expectedIDs: []int{3},
+		},
+		{
+			name: "Nested NOT in implicit AND group",
+			filter: []interface{}{
+				[]interface{}{"active", false},
+				[]interface{}{"!", []interface{}{"name", "Beta"}},
+			},
+			expectedIDs: []int{4},
+		},
+		{
+			name:        "anyof",
+			filter:      []interface{}{"name", "anyof", []interface{}{"Alpha", "Delta"}},
+			expectedIDs: []int{1, 4},
+		},
+		{
+			name:        "noneof",
+			filter:      []interface{}{"qty", "noneof", []interface{}{10, 40}},
+			expectedIDs: []int{2, 3},
+		},
+		{
+			name:        "Leading operator",
+			filter:      []interface{}{"or", []interface{}{"id", "=", 1}},
+			expectError: true,
+		},
+		{
+			name:        "Dangling operator",
+			filter:      []interface{}{[]interface{}{"id", "=", 1}, "and"},
+			expectError: true,
+		},
+		{
+			name:        "Invalid logical operator",
+			filter:      []interface{}{[]interface{}{"id", "=", 1}, "xor", []interface{}{"id", "=", 2}},
+			expectError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := FilterDynamicData(testRecords(), schema, tc.filter)
+			if tc.expectError {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", matchedIDs(result))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(result)
+			if len(got) != len(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+			for i := range got {
+				if got[i] != tc.expectedIDs[i] {
+					t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterDynamicDataNulls(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "note", Type: "string"},
+		schematool.SchemaFieldDefinition{Name: "due", Type: "time.Time"},
+	)
+	records := []map[string]interface{}{
+		{"id": float64(1), "note": "call back", "due": "2024-01-05T00:00:00Z"},
+		{"id": float64(2), "note": "", "due": nil},
+		{"id": float64(3)},
+	}
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		expectedIDs []int
+	}{
+		{name: "= null matches missing and null", filter: []interface{}{"due", "=", nil}, expectedIDs: []int{2, 3}},
+		{name: "<> null", filter: []interface{}{"due", "<>", nil}, expectedIDs: []int{1}},
+		{name: "isblank on string includes empty", filter: []interface{}{"note", "isblank", nil}, expectedIDs: []int{2, 3}},
+		{name: "isnotblank on string", filter: []interface{}{"note", "isnotblank", nil}, expectedIDs: []int{1}},
+		{name: "Null never matches a value comparison", filter: []interface{}{"note", "<>", "call back"}, expectedIDs: []int{2}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := FilterDynamicData(records, schema, tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(result)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+		})
+	}
+}
+
+func TestFilterDynamicDataWholeDayDates(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "due", Type: "time.Time"},
+	)
+	records := []map[string]interface{}{
+		{"id": float64(1), "due": "2024-01-04T23:59:59Z"},
+		{"id": float64(2), "due": "2024-01-05T00:00:00Z"},
+		{"id": float64(3), "due": "2024-01-05T13:07:00Z"},
+		{"id": float64(4), "due": "2024-01-06"},
+	}
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		expectedIDs []int
+	}{
+		{name: "= date", filter: []interface{}{"due", "=", "2024-01-05"}, expectedIDs: []int{2, 3}},
+		{name: "<> date", filter: []interface{}{"due", "<>", "2024-01-05"}, expectedIDs: []int{1, 4}},
+		{name: "< date", filter: []interface{}{"due", "<", "2024-01-05"}, expectedIDs: []int{1}},
+		{name: "<= date", filter: []interface{}{"due", "<=", "2024-01-05"}, expectedIDs: []int{1, 2, 3}},
+		{name: "> date", filter: []interface{}{"due", ">", "2024-01-05"}, expectedIDs: []int{4}},
+		{name: ">= date", filter: []interface{}{"due", ">=", "2024-01-05"}, expectedIDs: []int{2, 3, 4}},
+		{name: "between dates", filter: []interface{}{"due", "between", []interface{}{"2024-01-05", "2024-01-05"}}, expectedIDs: []int{2, 3}},
+		{name: "noneof dates", filter: []interface{}{"due", "noneof", []interface{}{"2024-01-04", "2024-01-06"}}, expectedIDs: []int{2, 3}},
+		{name: "= timestamp stays exact", filter: []interface{}{"due", "=", "2024-01-05T00:00:00Z"}, expectedIDs: []int{2}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := FilterDynamicData(records, schema, tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(result)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+		})
+	}
+}
+
+func TestFilterDynamicDataDateParts(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "due", Type: "time.Time"},
+	)
+	records := []map[string]interface{}{
+		{"id": float64(1), "due": "2024-01-06T09:30:00Z"}, // Saturday, Q1
+		{"id": float64(2), "due": "2024-05-15T18:00:00Z"}, // Wednesday, Q2
+		{"id": float64(3), "due": "2023-11-26"},           // Sunday, Q4
+		{"id": float64(4), "due": nil},
+	}
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		expectedIDs []int
+	}{
+		{name: "weekend", filter: []interface{}{"due.DayOfWeek", "anyof", []interface{}{0, 6}}, expectedIDs: []int{1, 3}},
+		{name: "quarter", filter: []interface{}{"due.Quarter", "=", 4}, expectedIDs: []int{3}},
+		{name: "year", filter: []interface{}{"due.year", "<", 2024}, expectedIDs: []int{3}},
+		{name: "month range", filter: []interface{}{"due.Month", "between", []interface{}{1, 6}}, expectedIDs: []int{1, 2}},
+		{name: "hour", filter: []interface{}{"due.Hour", ">", 12}, expectedIDs: []int{2}},
+		{name: "day", filter: []interface{}{"due.Day", "=", 15}, expectedIDs: []int{2}},
+		{name: "null date has no parts", filter: []interface{}{"due.Year", "=", nil}, expectedIDs: []int{4}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := FilterDynamicData(records, schema, tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(result)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+		})
+	}
+}
+
+func TestFilterDynamicDataFieldComparisons(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "cost", Type: "float64"},
+		schematool.SchemaFieldDefinition{Name: "price", Type: "float64"},
+		schematool.SchemaFieldDefinition{Name: "qty", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "created", Type: "time.Time"},
+		schematool.SchemaFieldDefinition{Name: "updated", Type: "time.Time"},
+	)
+	records := []map[string]interface{}{
+		{"id": float64(1), "cost": 10.0, "price": 15.0, "qty": float64(12), "created": "2024-01-01T00:00:00Z", "updated": "2024-02-01T00:00:00Z"},
+		{"id": float64(2), "cost": 20.0, "price": 18.0, "qty": float64(20), "created": "2024-01-01T00:00:00Z", "updated": "2024-01-01T00:00:00Z"},
+		{"id": float64(3), "cost": 5.0, "price": 5.0, "qty": nil, "created": "2024-03-01T00:00:00Z"},
+	}
+	ref := func(name string) map[string]interface{} { return map[string]interface{}{"field": name} }
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		expectedIDs []int
+	}{
+		{name: "float > float", filter: []interface{}{"price", ">", ref("cost")}, expectedIDs: []int{1}},
+		{name: "float = float", filter: []interface{}{"price", "=", ref("cost")}, expectedIDs: []int{3}},
+		{name: "int against float", filter: []interface{}{"qty", ">=", ref("cost")}, expectedIDs: []int{1, 2}},
+		{name: "time against time", filter: []interface{}{"updated", ">", ref("created")}, expectedIDs: []int{1}},
+		{name: "missing values never match", filter: []interface{}{"updated", "<>", ref("created")}, expectedIDs: []int{1}},
+		{name: "date parts", filter: []interface{}{"updated.Month", "=", ref("created.Month")}, expectedIDs: []int{2}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := FilterDynamicData(records, schema, tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(result)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+		})
+	}
+
+	if _, err := FilterDynamicData(records, schema, []interface{}{"price", ">", ref("created")}); err == nil {
+		t.Error("expected an error comparing a float with a time field")
+	}
+}
+
+func TestFilterDynamicDataComputedFields(t *testing.T) {
+	schema := &TableSchema{EntityName: "test", Fields: []schematool.SchemaFieldDefinition{
+		{Name: "id", Type: "int"},
+		{Name: "name", Type: "string"},
+		{Name: "cost", Type: "float64"},
+		{Name: "price", Type: "float64"},
+		{Name: "margin", Expression: "price - cost"},
+		{Name: "label", Type: "string", Expression: "name || '!'"},
+	}}
+	if err := schema.Index(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schema.FieldMap["margin"].Type != "float64" {
+		t.Errorf("expected margin to be inferred as float64, got %q", schema.FieldMap["margin"].Type)
+	}
+	records := []map[string]interface{}{
+		{"id": float64(1), "name": "Alpha", "cost": 10.0, "price": 15.0},
+		{"id": float64(2), "name": "Beta", "cost": 20.0, "price": 28.0},
+		{"id": float64(3), "name": "Gamma", "cost": 5.0},
+	}
+	result, err := FilterDynamicData(records, schema, []interface{}{"margin", ">", 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sorts, err := filterast.ParseSort([]interface{}{map[string]interface{}{"selector": "margin", "desc": true}}, schema.FieldMap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	SortDynamicData(result, sorts)
+	if got := matchedIDs(result); fmt.Sprint(got) != "[2 1]" {
+		t.Fatalf("expected ids [2 1], got %v", got)
+	}
+	if result[0]["margin"] != 8.0 || result[0]["label"] != "Beta!" {
+		t.Errorf("expected computed values in results, got %v", result[0])
+	}
+	if _, ok := records[0]["margin"]; ok {
+		t.Error("input records must not be modified")
+	}
+
+	all, _ := FilterDynamicData(records, schema, nil)
+	sorts, _ = filterast.ParseSort([]interface{}{"margin"}, schema.FieldMap)
+	SortDynamicData(all, sorts)
+	if got := matchedIDs(all); fmt.Sprint(got) != "[3 1 2]" {
+		t.Errorf("expected the null margin first, got %v", got)
+	}
+
+	for _, fields := range [][]schematool.SchemaFieldDefinition{
+		{{Name: "a", Type: "int"}, {Name: "b", Type: "string", Expression: "a + 1"}},
+		{{Name: "a", Type: "int"}, {Name: "b", Expression: "a + c"}},
+		{{Name: "a", Type: "int"}, {Name: "b", Expression: "a + 1"}, {Name: "c", Expression: "b + 1"}},
+		{{Name: "a", Type: "int"}, {Name: "b", Expression: "a + 1", Required: true}},
+		{{Name: "a", Type: "int", Values: []interface{}{1, "two"}}},
+	} {
+		bad := &TableSchema{Fields: fields}
+		if err := bad.Index(); err == nil {
+			t.Errorf("expected an error for %+v", fields)
+		}
+	}
+}
+
+func TestFilterDynamicDataPatternOperators(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "sku", Type: "string"},
+	)
+	records := []map[string]interface{}{
+		{"id": float64(1), "sku": "SKU-001-A"},
+		{"id": float64(2), "sku": "SKU-002-A"},
+		{"id": float64(3), "sku": "sku-010-b"},
+		{"id": float64(4), "sku": "SKU.00X-A"},
+		{"id": float64(5)},
+	}
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		expectedIDs []int
+	}{
+		{name: "matches", filter: []interface{}{"sku", "matches", `^SKU-00\d-A$`}, expectedIDs: []int{1, 2}},
+		{name: "matches is case-sensitive", filter: []interface{}{"sku", "matches", `-b$`}, expectedIDs: []int{3}},
+		{name: "like with underscore", filter: []interface{}{"sku", "like", "SKU-00_-A"}, expectedIDs: []int{1, 2}},
+		{name: "like is case-sensitive", filter: []interface{}{"sku", "like", "sku-0%"}, expectedIDs: []int{3}},
+		{name: "like escapes regex characters", filter: []interface{}{"sku", "like", "SKU.%"}, expectedIDs: []int{4}},
+		{name: "null never matches", filter: []interface{}{"sku", "like", "%"}, expectedIDs: []int{1, 2, 3, 4}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := FilterDynamicData(records, schema, tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(result)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+		})
+	}
+
+	_, err := FilterDynamicData(records, schema, []interface{}{"sku", "matches", "a(b"})
+	var validationErrs filterast.ValidationErrors
+	if !errors.As(err, &validationErrs) || validationErrs[0].Code != filterast.CodeInvalidValue {
+		t.Errorf("expected an invalid_value error for a bad regex, got %v", err)
+	}
+}
+
+func TestFilterDynamicDataFuzzy(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "description", Type: "string"},
+	)
+	records := []map[string]interface{}{
+		{"id": float64(1), "description": "Transfer 42"},
+		{"id": float64(2), "description": "Transaction fee"},
+		{"id": float64(3), "description": "Card Transactoin"},
+		{"id": float64(4), "description": "Cafe"},
+		{"id": float64(5)},
+	}
+	fuzzy := func(value string, distance int) interface{} {
+		return []interface{}{"description", "fuzzy", map[string]interface{}{"value": value, "distance": float64(distance)}}
+	}
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		expectedIDs []int
+	}{
+		{name: "typo within distance", filter: fuzzy("Tranzaction", 1), expectedIDs: []int{2}},
+		{name: "transposition costs two edits", filter: fuzzy("Tranzaction", 3), expectedIDs: []int{2, 3}},
+		{name: "distance zero is an exact word match", filter: fuzzy("Cafe", 0), expectedIDs: []int{4}},
+		{name: "case counts under the exact collation", filter: fuzzy("CAFE", 0), expectedIDs: []int{}},
+		{name: "multi-word search", filter: fuzzy("Card Transaction", 2), expectedIDs: []int{3}},
+		{name: "distance defaults to two", filter: []interface{}{"description", "fuzzy", map[string]interface{}{"value": "Transfr"}}, expectedIDs: []int{1}},
+		{name: "negated, null stays unmatched", filter: []interface{}{"!", fuzzy("Tranzaction", 3)}, expectedIDs: []int{1, 4}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := FilterDynamicData(records, schema, tc.filter)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(result)
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+		})
+	}
+
+	root, err := filterast.Parse([]interface{}{fuzzy("Transaction", 20), "or", []interface{}{"id", "=", 5}}, schema.FieldMap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ranked := append([]map[string]interface{}(nil), records...)
+	RankByFuzzyDistance(ranked, filterast.FuzzyConditions(root))
+	if got := fmt.Sprint(matchedIDs(ranked)); got != "[2 3 1 4 5]" {
+		t.Errorf("expected ranking [2 3 1 4 5], got %s", got)
+	}
+}
+
+func TestFilterDynamicDataCollations(t *testing.T) {
+	schema := newTestSchema(
+		schematool.SchemaFieldDefinition{Name: "id", Type: "int"},
+		schematool.SchemaFieldDefinition{Name: "merchant", Type: "string"},
+		schematool.SchemaFieldDefinition{Name: "code", Type: "string", Collation: filterast.CollationCaseInsensitive},
+		schematool.SchemaFieldDefinition{Name: "city", Type: "string", Collation: filterast.CollationAccentInsensitive},
+	)
+	records := []map[string]interface{}{
+		{"id": float64(1), "merchant": "Café Zoë", "code": "AB-1", "city": "Zürich"},
+		{"id": float64(2), "merchant": "CAFE ZOE", "code": "ab-1", "city": "Zurich"},
+		{"id": float64(3), "merchant": "Straße 9", "code": "AB-2", "city": "Genève"},
+	}
+	testCases := []struct {
+		name        string
+		filter      interface{}
+		collation   string
+		expectedIDs []int
+	}{
+		{name: "default is exact", filter: []interface{}{"merchant", "=", "café zoë"}, expectedIDs: []int{}},
+		{name: "case-insensitive field", filter: []interface{}{"code", "anyof", []interface{}{"ab-1", "AB-2"}}, expectedIDs: []int{1, 2, 3}},
+		{name: "request overrides to case-insensitive, not accents", filter: []interface{}{"merchant", "=", "café zoë"}, collation: filterast.CollationCaseInsensitive, expectedIDs: []int{1}},
+		{name: "case-insensitive folds ß", filter: []interface{}{"merchant", "startswith", "STRASSE"}, collation: filterast.CollationCaseInsensitive, expectedIDs: []int{3}},
+		{name: "accent-insensitive field", filter: []interface{}{"city", "contains", "ZUR"}, expectedIDs: []int{1, 2}},
+		{name: "request overrides to accent-insensitive", filter: []interface{}{"merchant", "=", "cafe zoe"}, collation: filterast.CollationAccentInsensitive, expectedIDs: []int{1, 2}},
+		{name: "request overrides to exact", filter: []interface{}{"city", "like", "z%"}, collation: filterast.CollationExact, expectedIDs: []int{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, err := filterast.Parse(tc.filter, schema.FieldMap)
+			if err == nil {
+				err = filterast.SetCollation(root, tc.collation)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := matchedIDs(MatchDynamicData(records, schema, root))
+			if fmt.Sprint(got) != fmt.Sprint(tc.expectedIDs) {
+				t.Fatalf("expected ids %v, got %v", tc.expectedIDs, got)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ var (
 	// Test1schemasColumns holds the columns for the "test1schemas" table.
 	Test1schemasColumns = []*schema.Column{
 		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "version", Type: field.TypeInt, Default: 1},
 		{Name: "field_string", Type: field.TypeString, Default: "default string"},
 		{Name: "field_int", Type: field.TypeInt, Default: 0},
 		{Name: "field_float", Type: field.TypeFloat64, Default: 0},
@@ -27,6 +28,7 @@ var (
 	// Test2schemasColumns holds the columns for the "test2schemas" table.
 	Test2schemasColumns = []*schema.Column{
 		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "version", Type: field.TypeInt, Default: 1},
 		{Name: "name", Type: field.TypeString, Default: "Unknown Name"},
 		{Name: "description", Type: field.TypeString, Nullable: true, Size: 2147483647},
 		{Name: "quantity", Type: field.TypeInt, Default: 0},
@@ -45,6 +47,7 @@ var (
 	// Test3schemasColumns holds the columns for the "test3schemas" table.
 	Test3schemasColumns = []*schema.Column{
 		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "version", Type: field.TypeInt, Default: 1},
 		{Name: "sku", Type: field.TypeString, Unique: true},
 		{Name: "product_name", Type: field.TypeString, Default: "Unnamed Product"},
 		{Name: "short_description", Type: field.TypeString, Nullable: true},
@@ -66,6 +69,7 @@ var (
 	// TransactionsColumns holds the columns for the "transactions" table.
 	TransactionsColumns = []*schema.Column{
 		{Name: "id", Type: field.TypeInt, Increment: true},
+		{Name: "version", Type: field.TypeInt, Default: 1},
 		{Name: "date", Type: field.TypeTime},
 		{Name: "amount", Type: field.TypeFloat64},
 		{Name: "name", Type: field.TypeString},
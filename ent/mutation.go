@@ -39,6 +39,8 @@ type Test1SchemaMutation struct {
 	op             Op
 	typ            string
 	id             *int
+	version        *int
+	addversion     *int
 	field_string   *string
 	field_int      *int
 	addfield_int   *int
@@ -151,6 +153,62 @@ func (m *Test1SchemaMutation) IDs(ctx context.Context) ([]int, error) {
 	}
 }
 
+// SetVersion sets the "version" field.
+func (m *Test1SchemaMutation) SetVersion(i int) {
+	m.version = &i
+	m.addversion = nil
+}
+
+// Version returns the value of the "version" field in the mutation.
+func (m *Test1SchemaMutation) Version() (r int, exists bool) {
+	v := m.version
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldVersion returns the old "version" field's value of the Test1Schema entity.
+// If the Test1Schema object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *Test1SchemaMutation) OldVersion(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldVersion is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldVersion requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldVersion: %w", err)
+	}
+	return oldValue.Version, nil
+}
+
+// AddVersion adds i to the "version" field.
+func (m *Test1SchemaMutation) AddVersion(i int) {
+	if m.addversion != nil {
+		*m.addversion += i
+	} else {
+		m.addversion = &i
+	}
+}
+
+// AddedVersion returns the value that was added to the "version" field in this mutation.
+func (m *Test1SchemaMutation) AddedVersion() (r int, exists bool) {
+	v := m.addversion
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetVersion resets all changes to the "version" field.
+func (m *Test1SchemaMutation) ResetVersion() {
+	m.version = nil
+	m.addversion = nil
+}
+
 // SetFieldString sets the "field_string" field.
 func (m *Test1SchemaMutation) SetFieldString(s string) {
 	m.field_string = &s
@@ -454,7 +512,10 @@ func (m *Test1SchemaMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *Test1SchemaMutation) Fields() []string {
-	fields := make([]string, 0, 6)
+	fields := make([]string, 0, 7)
+	if m.version != nil {
+		fields = append(fields, test1schema.FieldVersion)
+	}
 	if m.field_string != nil {
 		fields = append(fields, test1schema.FieldFieldString)
 	}
@@ -481,6 +542,8 @@ func (m *Test1SchemaMutation) Fields() []string {
 // schema.
 func (m *Test1SchemaMutation) Field(name string) (ent.Value, bool) {
 	switch name {
+	case test1schema.FieldVersion:
+		return m.Version()
 	case test1schema.FieldFieldString:
 		return m.FieldString()
 	case test1schema.FieldFieldInt:
@@ -502,6 +565,8 @@ func (m *Test1SchemaMutation) Field(name string) (ent.Value, bool) {
 // database failed.
 func (m *Test1SchemaMutation) OldField(ctx context.Context, name string) (ent.Value, error) {
 	switch name {
+	case test1schema.FieldVersion:
+		return m.OldVersion(ctx)
 	case test1schema.FieldFieldString:
 		return m.OldFieldString(ctx)
 	case test1schema.FieldFieldInt:
@@ -523,6 +588,13 @@ func (m *Test1SchemaMutation) OldField(ctx context.Context, name string) (ent.Va
 // type.
 func (m *Test1SchemaMutation) SetField(name string, value ent.Value) error {
 	switch name {
+	case test1schema.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetVersion(v)
+		return nil
 	case test1schema.FieldFieldString:
 		v, ok := value.(string)
 		if !ok {
@@ -573,6 +645,9 @@ func (m *Test1SchemaMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *Test1SchemaMutation) AddedFields() []string {
 	var fields []string
+	if m.addversion != nil {
+		fields = append(fields, test1schema.FieldVersion)
+	}
 	if m.addfield_int != nil {
 		fields = append(fields, test1schema.FieldFieldInt)
 	}
@@ -587,6 +662,8 @@ func (m *Test1SchemaMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *Test1SchemaMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case test1schema.FieldVersion:
+		return m.AddedVersion()
 	case test1schema.FieldFieldInt:
 		return m.AddedFieldInt()
 	case test1schema.FieldFieldFloat:
@@ -600,6 +677,13 @@ func (m *Test1SchemaMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *Test1SchemaMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case test1schema.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddVersion(v)
+		return nil
 	case test1schema.FieldFieldInt:
 		v, ok := value.(int)
 		if !ok {
@@ -650,6 +734,9 @@ func (m *Test1SchemaMutation) ClearField(name string) error {
 // It returns an error if the field is not defined in the schema.
 func (m *Test1SchemaMutation) ResetField(name string) error {
 	switch name {
+	case test1schema.FieldVersion:
+		m.ResetVersion()
+		return nil
 	case test1schema.FieldFieldString:
 		m.ResetFieldString()
 		return nil
@@ -726,6 +813,8 @@ type Test2SchemaMutation struct {
 	op            Op
 	typ           string
 	id            *int
+	version       *int
+	addversion    *int
 	name          *string
 	description   *string
 	quantity      *int
@@ -840,6 +929,62 @@ func (m *Test2SchemaMutation) IDs(ctx context.Context) ([]int, error) {
 	}
 }
 
+// SetVersion sets the "version" field.
+func (m *Test2SchemaMutation) SetVersion(i int) {
+	m.version = &i
+	m.addversion = nil
+}
+
+// Version returns the value of the "version" field in the mutation.
+func (m *Test2SchemaMutation) Version() (r int, exists bool) {
+	v := m.version
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldVersion returns the old "version" field's value of the Test2Schema entity.
+// If the Test2Schema object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *Test2SchemaMutation) OldVersion(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldVersion is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldVersion requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldVersion: %w", err)
+	}
+	return oldValue.Version, nil
+}
+
+// AddVersion adds i to the "version" field.
+func (m *Test2SchemaMutation) AddVersion(i int) {
+	if m.addversion != nil {
+		*m.addversion += i
+	} else {
+		m.addversion = &i
+	}
+}
+
+// AddedVersion returns the value that was added to the "version" field in this mutation.
+func (m *Test2SchemaMutation) AddedVersion() (r int, exists bool) {
+	v := m.addversion
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetVersion resets all changes to the "version" field.
+func (m *Test2SchemaMutation) ResetVersion() {
+	m.version = nil
+	m.addversion = nil
+}
+
 // SetName sets the "name" field.
 func (m *Test2SchemaMutation) SetName(s string) {
 	m.name = &s
@@ -1228,7 +1373,10 @@ func (m *Test2SchemaMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *Test2SchemaMutation) Fields() []string {
-	fields := make([]string, 0, 8)
+	fields := make([]string, 0, 9)
+	if m.version != nil {
+		fields = append(fields, test2schema.FieldVersion)
+	}
 	if m.name != nil {
 		fields = append(fields, test2schema.FieldName)
 	}
@@ -1261,6 +1409,8 @@ func (m *Test2SchemaMutation) Fields() []string {
 // schema.
 func (m *Test2SchemaMutation) Field(name string) (ent.Value, bool) {
 	switch name {
+	case test2schema.FieldVersion:
+		return m.Version()
 	case test2schema.FieldName:
 		return m.Name()
 	case test2schema.FieldDescription:
@@ -1286,6 +1436,8 @@ func (m *Test2SchemaMutation) Field(name string) (ent.Value, bool) {
 // database failed.
 func (m *Test2SchemaMutation) OldField(ctx context.Context, name string) (ent.Value, error) {
 	switch name {
+	case test2schema.FieldVersion:
+		return m.OldVersion(ctx)
 	case test2schema.FieldName:
 		return m.OldName(ctx)
 	case test2schema.FieldDescription:
@@ -1311,6 +1463,13 @@ func (m *Test2SchemaMutation) OldField(ctx context.Context, name string) (ent.Va
 // type.
 func (m *Test2SchemaMutation) SetField(name string, value ent.Value) error {
 	switch name {
+	case test2schema.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetVersion(v)
+		return nil
 	case test2schema.FieldName:
 		v, ok := value.(string)
 		if !ok {
@@ -1375,6 +1534,9 @@ func (m *Test2SchemaMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *Test2SchemaMutation) AddedFields() []string {
 	var fields []string
+	if m.addversion != nil {
+		fields = append(fields, test2schema.FieldVersion)
+	}
 	if m.addquantity != nil {
 		fields = append(fields, test2schema.FieldQuantity)
 	}
@@ -1389,6 +1551,8 @@ func (m *Test2SchemaMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *Test2SchemaMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case test2schema.FieldVersion:
+		return m.AddedVersion()
 	case test2schema.FieldQuantity:
 		return m.AddedQuantity()
 	case test2schema.FieldPrice:
@@ -1402,6 +1566,13 @@ func (m *Test2SchemaMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *Test2SchemaMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case test2schema.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddVersion(v)
+		return nil
 	case test2schema.FieldQuantity:
 		v, ok := value.(int)
 		if !ok {
@@ -1458,6 +1629,9 @@ func (m *Test2SchemaMutation) ClearField(name string) error {
 // It returns an error if the field is not defined in the schema.
 func (m *Test2SchemaMutation) ResetField(name string) error {
 	switch name {
+	case test2schema.FieldVersion:
+		m.ResetVersion()
+		return nil
 	case test2schema.FieldName:
 		m.ResetName()
 		return nil
@@ -1540,6 +1714,8 @@ type Test3SchemaMutation struct {
 	op                Op
 	typ               string
 	id                *int
+	version           *int
+	addversion        *int
 	sku               *string
 	product_name      *string
 	short_description *string
@@ -1658,6 +1834,62 @@ func (m *Test3SchemaMutation) IDs(ctx context.Context) ([]int, error) {
 	}
 }
 
+// SetVersion sets the "version" field.
+func (m *Test3SchemaMutation) SetVersion(i int) {
+	m.version = &i
+	m.addversion = nil
+}
+
+// Version returns the value of the "version" field in the mutation.
+func (m *Test3SchemaMutation) Version() (r int, exists bool) {
+	v := m.version
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldVersion returns the old "version" field's value of the Test3Schema entity.
+// If the Test3Schema object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *Test3SchemaMutation) OldVersion(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldVersion is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldVersion requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldVersion: %w", err)
+	}
+	return oldValue.Version, nil
+}
+
+// AddVersion adds i to the "version" field.
+func (m *Test3SchemaMutation) AddVersion(i int) {
+	if m.addversion != nil {
+		*m.addversion += i
+	} else {
+		m.addversion = &i
+	}
+}
+
+// AddedVersion returns the value that was added to the "version" field in this mutation.
+func (m *Test3SchemaMutation) AddedVersion() (r int, exists bool) {
+	v := m.addversion
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetVersion resets all changes to the "version" field.
+func (m *Test3SchemaMutation) ResetVersion() {
+	m.version = nil
+	m.addversion = nil
+}
+
 // SetSku sets the "sku" field.
 func (m *Test3SchemaMutation) SetSku(s string) {
 	m.sku = &s
@@ -2213,7 +2445,10 @@ func (m *Test3SchemaMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *Test3SchemaMutation) Fields() []string {
-	fields := make([]string, 0, 11)
+	fields := make([]string, 0, 12)
+	if m.version != nil {
+		fields = append(fields, test3schema.FieldVersion)
+	}
 	if m.sku != nil {
 		fields = append(fields, test3schema.FieldSku)
 	}
@@ -2255,6 +2490,8 @@ func (m *Test3SchemaMutation) Fields() []string {
 // schema.
 func (m *Test3SchemaMutation) Field(name string) (ent.Value, bool) {
 	switch name {
+	case test3schema.FieldVersion:
+		return m.Version()
 	case test3schema.FieldSku:
 		return m.Sku()
 	case test3schema.FieldProductName:
@@ -2286,6 +2523,8 @@ func (m *Test3SchemaMutation) Field(name string) (ent.Value, bool) {
 // database failed.
 func (m *Test3SchemaMutation) OldField(ctx context.Context, name string) (ent.Value, error) {
 	switch name {
+	case test3schema.FieldVersion:
+		return m.OldVersion(ctx)
 	case test3schema.FieldSku:
 		return m.OldSku(ctx)
 	case test3schema.FieldProductName:
@@ -2317,6 +2556,13 @@ func (m *Test3SchemaMutation) OldField(ctx context.Context, name string) (ent.Va
 // type.
 func (m *Test3SchemaMutation) SetField(name string, value ent.Value) error {
 	switch name {
+	case test3schema.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetVersion(v)
+		return nil
 	case test3schema.FieldSku:
 		v, ok := value.(string)
 		if !ok {
@@ -2402,6 +2648,9 @@ func (m *Test3SchemaMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *Test3SchemaMutation) AddedFields() []string {
 	var fields []string
+	if m.addversion != nil {
+		fields = append(fields, test3schema.FieldVersion)
+	}
 	if m.addcost_price != nil {
 		fields = append(fields, test3schema.FieldCostPrice)
 	}
@@ -2419,6 +2668,8 @@ func (m *Test3SchemaMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *Test3SchemaMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case test3schema.FieldVersion:
+		return m.AddedVersion()
 	case test3schema.FieldCostPrice:
 		return m.AddedCostPrice()
 	case test3schema.FieldRetailPrice:
@@ -2434,6 +2685,13 @@ func (m *Test3SchemaMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *Test3SchemaMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case test3schema.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddVersion(v)
+		return nil
 	case test3schema.FieldCostPrice:
 		v, ok := value.(float64)
 		if !ok {
@@ -2515,6 +2773,9 @@ func (m *Test3SchemaMutation) ClearField(name string) error {
 // It returns an error if the field is not defined in the schema.
 func (m *Test3SchemaMutation) ResetField(name string) error {
 	switch name {
+	case test3schema.FieldVersion:
+		m.ResetVersion()
+		return nil
 	case test3schema.FieldSku:
 		m.ResetSku()
 		return nil
@@ -2606,6 +2867,8 @@ type TransactionMutation struct {
 	op            Op
 	typ           string
 	id            *int
+	version       *int
+	addversion    *int
 	date          *time.Time
 	amount        *float64
 	addamount     *float64
@@ -2717,6 +2980,62 @@ func (m *TransactionMutation) IDs(ctx context.Context) ([]int, error) {
 	}
 }
 
+// SetVersion sets the "version" field.
+func (m *TransactionMutation) SetVersion(i int) {
+	m.version = &i
+	m.addversion = nil
+}
+
+// Version returns the value of the "version" field in the mutation.
+func (m *TransactionMutation) Version() (r int, exists bool) {
+	v := m.version
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldVersion returns the old "version" field's value of the Transaction entity.
+// If the Transaction object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *TransactionMutation) OldVersion(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldVersion is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldVersion requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldVersion: %w", err)
+	}
+	return oldValue.Version, nil
+}
+
+// AddVersion adds i to the "version" field.
+func (m *TransactionMutation) AddVersion(i int) {
+	if m.addversion != nil {
+		*m.addversion += i
+	} else {
+		m.addversion = &i
+	}
+}
+
+// AddedVersion returns the value that was added to the "version" field in this mutation.
+func (m *TransactionMutation) AddedVersion() (r int, exists bool) {
+	v := m.addversion
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetVersion resets all changes to the "version" field.
+func (m *TransactionMutation) ResetVersion() {
+	m.version = nil
+	m.addversion = nil
+}
+
 // SetDate sets the "date" field.
 func (m *TransactionMutation) SetDate(t time.Time) {
 	m.date = &t
@@ -2987,7 +3306,10 @@ func (m *TransactionMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *TransactionMutation) Fields() []string {
-	fields := make([]string, 0, 6)
+	fields := make([]string, 0, 7)
+	if m.version != nil {
+		fields = append(fields, transaction.FieldVersion)
+	}
 	if m.date != nil {
 		fields = append(fields, transaction.FieldDate)
 	}
@@ -3014,6 +3336,8 @@ func (m *TransactionMutation) Fields() []string {
 // schema.
 func (m *TransactionMutation) Field(name string) (ent.Value, bool) {
 	switch name {
+	case transaction.FieldVersion:
+		return m.Version()
 	case transaction.FieldDate:
 		return m.Date()
 	case transaction.FieldAmount:
@@ -3035,6 +3359,8 @@ func (m *TransactionMutation) Field(name string) (ent.Value, bool) {
 // database failed.
 func (m *TransactionMutation) OldField(ctx context.Context, name string) (ent.Value, error) {
 	switch name {
+	case transaction.FieldVersion:
+		return m.OldVersion(ctx)
 	case transaction.FieldDate:
 		return m.OldDate(ctx)
 	case transaction.FieldAmount:
@@ -3056,6 +3382,13 @@ func (m *TransactionMutation) OldField(ctx context.Context, name string) (ent.Va
 // type.
 func (m *TransactionMutation) SetField(name string, value ent.Value) error {
 	switch name {
+	case transaction.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetVersion(v)
+		return nil
 	case transaction.FieldDate:
 		v, ok := value.(time.Time)
 		if !ok {
@@ -3106,6 +3439,9 @@ func (m *TransactionMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *TransactionMutation) AddedFields() []string {
 	var fields []string
+	if m.addversion != nil {
+		fields = append(fields, transaction.FieldVersion)
+	}
 	if m.addamount != nil {
 		fields = append(fields, transaction.FieldAmount)
 	}
@@ -3117,6 +3453,8 @@ func (m *TransactionMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *TransactionMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case transaction.FieldVersion:
+		return m.AddedVersion()
 	case transaction.FieldAmount:
 		return m.AddedAmount()
 	}
@@ -3128,6 +3466,13 @@ func (m *TransactionMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *TransactionMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case transaction.FieldVersion:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddVersion(v)
+		return nil
 	case transaction.FieldAmount:
 		v, ok := value.(float64)
 		if !ok {
@@ -3162,6 +3507,9 @@ func (m *TransactionMutation) ClearField(name string) error {
 // It returns an error if the field is not defined in the schema.
 func (m *TransactionMutation) ResetField(name string) error {
 	switch name {
+	case transaction.FieldVersion:
+		m.ResetVersion()
+		return nil
 	case transaction.FieldDate:
 		m.ResetDate()
 		return nil
@@ -8,14 +8,22 @@ import (
 	"transaction-filter-backend/ent/test1schema"
 	"transaction-filter-backend/ent/test2schema"
 	"transaction-filter-backend/ent/test3schema"
+	"transaction-filter-backend/ent/transaction"
 )
 
 // The init function reads all schema descriptors with runtime code
 // (default values, validators, hooks and policies) and stitches it
 // to their package variables.
 func init() {
+	test1schemaMixin := schema.Test1Schema{}.Mixin()
+	test1schemaMixinFields0 := test1schemaMixin[0].Fields()
+	_ = test1schemaMixinFields0
 	test1schemaFields := schema.Test1Schema{}.Fields()
 	_ = test1schemaFields
+	// test1schemaDescVersion is the schema descriptor for version field.
+	test1schemaDescVersion := test1schemaMixinFields0[0].Descriptor()
+	// test1schema.DefaultVersion holds the default value on creation for the version field.
+	test1schema.DefaultVersion = test1schemaDescVersion.Default.(int)
 	// test1schemaDescFieldString is the schema descriptor for field_string field.
 	test1schemaDescFieldString := test1schemaFields[0].Descriptor()
 	// test1schema.DefaultFieldString holds the default value on creation for the field_string field.
@@ -36,8 +44,15 @@ func init() {
 	test1schemaDescFieldTime := test1schemaFields[4].Descriptor()
 	// test1schema.DefaultFieldTime holds the default value on creation for the field_time field.
 	test1schema.DefaultFieldTime = test1schemaDescFieldTime.Default.(func() time.Time)
+	test2schemaMixin := schema.Test2Schema{}.Mixin()
+	test2schemaMixinFields0 := test2schemaMixin[0].Fields()
+	_ = test2schemaMixinFields0
 	test2schemaFields := schema.Test2Schema{}.Fields()
 	_ = test2schemaFields
+	// test2schemaDescVersion is the schema descriptor for version field.
+	test2schemaDescVersion := test2schemaMixinFields0[0].Descriptor()
+	// test2schema.DefaultVersion holds the default value on creation for the version field.
+	test2schema.DefaultVersion = test2schemaDescVersion.Default.(int)
 	// test2schemaDescName is the schema descriptor for name field.
 	test2schemaDescName := test2schemaFields[0].Descriptor()
 	// test2schema.DefaultName holds the default value on creation for the name field.
@@ -64,8 +79,15 @@ func init() {
 	test2schema.DefaultUpdatedAt = test2schemaDescUpdatedAt.Default.(func() time.Time)
 	// test2schema.UpdateDefaultUpdatedAt holds the default value on update for the updated_at field.
 	test2schema.UpdateDefaultUpdatedAt = test2schemaDescUpdatedAt.UpdateDefault.(func() time.Time)
+	test3schemaMixin := schema.Test3Schema{}.Mixin()
+	test3schemaMixinFields0 := test3schemaMixin[0].Fields()
+	_ = test3schemaMixinFields0
 	test3schemaFields := schema.Test3Schema{}.Fields()
 	_ = test3schemaFields
+	// test3schemaDescVersion is the schema descriptor for version field.
+	test3schemaDescVersion := test3schemaMixinFields0[0].Descriptor()
+	// test3schema.DefaultVersion holds the default value on creation for the version field.
+	test3schema.DefaultVersion = test3schemaDescVersion.Default.(int)
 	// test3schemaDescSku is the schema descriptor for sku field.
 	test3schemaDescSku := test3schemaFields[0].Descriptor()
 	// test3schema.SkuValidator is a validator for the "sku" field. It is called by the builders before save.
@@ -90,4 +112,13 @@ func init() {
 	test3schemaDescIsActive := test3schemaFields[7].Descriptor()
 	// test3schema.DefaultIsActive holds the default value on creation for the is_active field.
 	test3schema.DefaultIsActive = test3schemaDescIsActive.Default.(bool)
+	transactionMixin := schema.Transaction{}.Mixin()
+	transactionMixinFields0 := transactionMixin[0].Fields()
+	_ = transactionMixinFields0
+	transactionFields := schema.Transaction{}.Fields()
+	_ = transactionFields
+	// transactionDescVersion is the schema descriptor for version field.
+	transactionDescVersion := transactionMixinFields0[0].Descriptor()
+	// transaction.DefaultVersion holds the default value on creation for the version field.
+	transaction.DefaultVersion = transactionDescVersion.Default.(int)
 }
@@ -13,6 +13,11 @@ type Test1Schema struct {
 	ent.Schema
 }
 
+// Mixin of the Test1Schema.
+func (Test1Schema) Mixin() []ent.Mixin {
+	return []ent.Mixin{VersionMixin{}}
+}
+
 // Fields of the Test1Schema.
 func (Test1Schema) Fields() []ent.Field {
 	return []ent.Field{
@@ -13,6 +13,11 @@ type Test2Schema struct {
 	ent.Schema
 }
 
+// Mixin of the Test2Schema.
+func (Test2Schema) Mixin() []ent.Mixin {
+	return []ent.Mixin{VersionMixin{}}
+}
+
 // Fields of the Test2Schema.
 func (Test2Schema) Fields() []ent.Field {
 	return []ent.Field{
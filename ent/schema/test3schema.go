@@ -11,6 +11,11 @@ type Test3Schema struct {
 	ent.Schema
 }
 
+// Mixin of the Test3Schema.
+func (Test3Schema) Mixin() []ent.Mixin {
+	return []ent.Mixin{VersionMixin{}}
+}
+
 // Fields of the Test3Schema.
 func (Test3Schema) Fields() []ent.Field {
 	return []ent.Field{
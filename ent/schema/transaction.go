@@ -10,6 +10,11 @@ type Transaction struct {
 	ent.Schema
 }
 
+// Mixin of the Transaction.
+func (Transaction) Mixin() []ent.Mixin {
+	return []ent.Mixin{VersionMixin{}}
+}
+
 // Fields of the Transaction.
 func (Transaction) Fields() []ent.Field {
 	return []ent.Field{
@@ -17,6 +17,8 @@ type Test1Schema struct {
 	config `json:"-"`
 	// ID of the ent.
 	ID int `json:"id,omitempty"`
+	// Version holds the value of the "version" field.
+	Version int `json:"version,omitempty"`
 	// FieldString holds the value of the "field_string" field.
 	FieldString string `json:"field_string,omitempty"`
 	// FieldInt holds the value of the "field_int" field.
@@ -41,7 +43,7 @@ func (*Test1Schema) scanValues(columns []string) ([]any, error) {
 			values[i] = new(sql.NullBool)
 		case test1schema.FieldFieldFloat:
 			values[i] = new(sql.NullFloat64)
-		case test1schema.FieldID, test1schema.FieldFieldInt:
+		case test1schema.FieldID, test1schema.FieldVersion, test1schema.FieldFieldInt:
 			values[i] = new(sql.NullInt64)
 		case test1schema.FieldFieldString, test1schema.FieldFieldText:
 			values[i] = new(sql.NullString)
@@ -68,6 +70,12 @@ func (t *Test1Schema) assignValues(columns []string, values []any) error {
 				return fmt.Errorf("unexpected type %T for field id", value)
 			}
 			t.ID = int(value.Int64)
+		case test1schema.FieldVersion:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field version", values[i])
+			} else if value.Valid {
+				t.Version = int(value.Int64)
+			}
 		case test1schema.FieldFieldString:
 			if value, ok := values[i].(*sql.NullString); !ok {
 				return fmt.Errorf("unexpected type %T for field field_string", values[i])
@@ -140,6 +148,9 @@ func (t *Test1Schema) String() string {
 	var builder strings.Builder
 	builder.WriteString("Test1Schema(")
 	builder.WriteString(fmt.Sprintf("id=%v, ", t.ID))
+	builder.WriteString("version=")
+	builder.WriteString(fmt.Sprintf("%v", t.Version))
+	builder.WriteString(", ")
 	builder.WriteString("field_string=")
 	builder.WriteString(t.FieldString)
 	builder.WriteString(", ")
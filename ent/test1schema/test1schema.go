@@ -13,6 +13,8 @@ const (
 	Label = "test1schema"
 	// FieldID holds the string denoting the id field in the database.
 	FieldID = "id"
+	// FieldVersion holds the string denoting the version field in the database.
+	FieldVersion = "version"
 	// FieldFieldString holds the string denoting the field_string field in the database.
 	FieldFieldString = "field_string"
 	// FieldFieldInt holds the string denoting the field_int field in the database.
@@ -32,6 +34,7 @@ const (
 // Columns holds all SQL columns for test1schema fields.
 var Columns = []string{
 	FieldID,
+	FieldVersion,
 	FieldFieldString,
 	FieldFieldInt,
 	FieldFieldFloat,
@@ -51,6 +54,8 @@ func ValidColumn(column string) bool {
 }
 
 var (
+	// DefaultVersion holds the default value on creation for the "version" field.
+	DefaultVersion int
 	// DefaultFieldString holds the default value on creation for the "field_string" field.
 	DefaultFieldString string
 	// DefaultFieldInt holds the default value on creation for the "field_int" field.
@@ -71,6 +76,11 @@ func ByID(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldID, opts...).ToFunc()
 }
 
+// ByVersion orders the results by the version field.
+func ByVersion(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldVersion, opts...).ToFunc()
+}
+
 // ByFieldString orders the results by the field_string field.
 func ByFieldString(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldFieldString, opts...).ToFunc()
@@ -54,6 +54,11 @@ func IDLTE(id int) predicate.Test1Schema {
 	return predicate.Test1Schema(sql.FieldLTE(FieldID, id))
 }
 
+// Version applies equality check predicate on the "version" field. It's identical to VersionEQ.
+func Version(v int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldEQ(FieldVersion, v))
+}
+
 // FieldString applies equality check predicate on the "field_string" field. It's identical to FieldStringEQ.
 func FieldString(v string) predicate.Test1Schema {
 	return predicate.Test1Schema(sql.FieldEQ(FieldFieldString, v))
@@ -84,6 +89,46 @@ func FieldText(v string) predicate.Test1Schema {
 	return predicate.Test1Schema(sql.FieldEQ(FieldFieldText, v))
 }
 
+// VersionEQ applies the EQ predicate on the "version" field.
+func VersionEQ(v int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldEQ(FieldVersion, v))
+}
+
+// VersionNEQ applies the NEQ predicate on the "version" field.
+func VersionNEQ(v int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldNEQ(FieldVersion, v))
+}
+
+// VersionIn applies the In predicate on the "version" field.
+func VersionIn(vs ...int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldIn(FieldVersion, vs...))
+}
+
+// VersionNotIn applies the NotIn predicate on the "version" field.
+func VersionNotIn(vs ...int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldNotIn(FieldVersion, vs...))
+}
+
+// VersionGT applies the GT predicate on the "version" field.
+func VersionGT(v int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldGT(FieldVersion, v))
+}
+
+// VersionGTE applies the GTE predicate on the "version" field.
+func VersionGTE(v int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldGTE(FieldVersion, v))
+}
+
+// VersionLT applies the LT predicate on the "version" field.
+func VersionLT(v int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldLT(FieldVersion, v))
+}
+
+// VersionLTE applies the LTE predicate on the "version" field.
+func VersionLTE(v int) predicate.Test1Schema {
+	return predicate.Test1Schema(sql.FieldLTE(FieldVersion, v))
+}
+
 // FieldStringEQ applies the EQ predicate on the "field_string" field.
 func FieldStringEQ(v string) predicate.Test1Schema {
 	return predicate.Test1Schema(sql.FieldEQ(FieldFieldString, v))
@@ -20,6 +20,20 @@ type Test1SchemaCreate struct {
 	hooks    []Hook
 }
 
+// SetVersion sets the "version" field.
+func (tc *Test1SchemaCreate) SetVersion(i int) *Test1SchemaCreate {
+	tc.mutation.SetVersion(i)
+	return tc
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tc *Test1SchemaCreate) SetNillableVersion(i *int) *Test1SchemaCreate {
+	if i != nil {
+		tc.SetVersion(*i)
+	}
+	return tc
+}
+
 // SetFieldString sets the "field_string" field.
 func (tc *Test1SchemaCreate) SetFieldString(s string) *Test1SchemaCreate {
 	tc.mutation.SetFieldString(s)
@@ -139,6 +153,10 @@ func (tc *Test1SchemaCreate) ExecX(ctx context.Context) {
 
 // defaults sets the default values of the builder before save.
 func (tc *Test1SchemaCreate) defaults() {
+	if _, ok := tc.mutation.Version(); !ok {
+		v := test1schema.DefaultVersion
+		tc.mutation.SetVersion(v)
+	}
 	if _, ok := tc.mutation.FieldString(); !ok {
 		v := test1schema.DefaultFieldString
 		tc.mutation.SetFieldString(v)
@@ -163,6 +181,9 @@ func (tc *Test1SchemaCreate) defaults() {
 
 // check runs all checks and user-defined validators on the builder.
 func (tc *Test1SchemaCreate) check() error {
+	if _, ok := tc.mutation.Version(); !ok {
+		return &ValidationError{Name: "version", err: errors.New(`ent: missing required field "Test1Schema.version"`)}
+	}
 	if _, ok := tc.mutation.FieldString(); !ok {
 		return &ValidationError{Name: "field_string", err: errors.New(`ent: missing required field "Test1Schema.field_string"`)}
 	}
@@ -204,6 +225,10 @@ func (tc *Test1SchemaCreate) createSpec() (*Test1Schema, *sqlgraph.CreateSpec) {
 		_node = &Test1Schema{config: tc.config}
 		_spec = sqlgraph.NewCreateSpec(test1schema.Table, sqlgraph.NewFieldSpec(test1schema.FieldID, field.TypeInt))
 	)
+	if value, ok := tc.mutation.Version(); ok {
+		_spec.SetField(test1schema.FieldVersion, field.TypeInt, value)
+		_node.Version = value
+	}
 	if value, ok := tc.mutation.FieldString(); ok {
 		_spec.SetField(test1schema.FieldFieldString, field.TypeString, value)
 		_node.FieldString = value
@@ -262,12 +262,12 @@ func (tq *Test1SchemaQuery) Clone() *Test1SchemaQuery {
 // Example:
 //
 //	var v []struct {
-//		FieldString string `json:"field_string,omitempty"`
+//		Version int `json:"version,omitempty"`
 //		Count int `json:"count,omitempty"`
 //	}
 //
 //	client.Test1Schema.Query().
-//		GroupBy(test1schema.FieldFieldString).
+//		GroupBy(test1schema.FieldVersion).
 //		Aggregate(ent.Count()).
 //		Scan(ctx, &v)
 func (tq *Test1SchemaQuery) GroupBy(field string, fields ...string) *Test1SchemaGroupBy {
@@ -285,11 +285,11 @@ func (tq *Test1SchemaQuery) GroupBy(field string, fields ...string) *Test1Schema
 // Example:
 //
 //	var v []struct {
-//		FieldString string `json:"field_string,omitempty"`
+//		Version int `json:"version,omitempty"`
 //	}
 //
 //	client.Test1Schema.Query().
-//		Select(test1schema.FieldFieldString).
+//		Select(test1schema.FieldVersion).
 //		Scan(ctx, &v)
 func (tq *Test1SchemaQuery) Select(fields ...string) *Test1SchemaSelect {
 	tq.ctx.Fields = append(tq.ctx.Fields, fields...)
@@ -28,6 +28,27 @@ func (tu *Test1SchemaUpdate) Where(ps ...predicate.Test1Schema) *Test1SchemaUpda
 	return tu
 }
 
+// SetVersion sets the "version" field.
+func (tu *Test1SchemaUpdate) SetVersion(i int) *Test1SchemaUpdate {
+	tu.mutation.ResetVersion()
+	tu.mutation.SetVersion(i)
+	return tu
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tu *Test1SchemaUpdate) SetNillableVersion(i *int) *Test1SchemaUpdate {
+	if i != nil {
+		tu.SetVersion(*i)
+	}
+	return tu
+}
+
+// AddVersion adds i to the "version" field.
+func (tu *Test1SchemaUpdate) AddVersion(i int) *Test1SchemaUpdate {
+	tu.mutation.AddVersion(i)
+	return tu
+}
+
 // SetFieldString sets the "field_string" field.
 func (tu *Test1SchemaUpdate) SetFieldString(s string) *Test1SchemaUpdate {
 	tu.mutation.SetFieldString(s)
@@ -173,6 +194,12 @@ func (tu *Test1SchemaUpdate) sqlSave(ctx context.Context) (n int, err error) {
 			}
 		}
 	}
+	if value, ok := tu.mutation.Version(); ok {
+		_spec.SetField(test1schema.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tu.mutation.AddedVersion(); ok {
+		_spec.AddField(test1schema.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tu.mutation.FieldString(); ok {
 		_spec.SetField(test1schema.FieldFieldString, field.TypeString, value)
 	}
@@ -220,6 +247,27 @@ type Test1SchemaUpdateOne struct {
 	mutation *Test1SchemaMutation
 }
 
+// SetVersion sets the "version" field.
+func (tuo *Test1SchemaUpdateOne) SetVersion(i int) *Test1SchemaUpdateOne {
+	tuo.mutation.ResetVersion()
+	tuo.mutation.SetVersion(i)
+	return tuo
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tuo *Test1SchemaUpdateOne) SetNillableVersion(i *int) *Test1SchemaUpdateOne {
+	if i != nil {
+		tuo.SetVersion(*i)
+	}
+	return tuo
+}
+
+// AddVersion adds i to the "version" field.
+func (tuo *Test1SchemaUpdateOne) AddVersion(i int) *Test1SchemaUpdateOne {
+	tuo.mutation.AddVersion(i)
+	return tuo
+}
+
 // SetFieldString sets the "field_string" field.
 func (tuo *Test1SchemaUpdateOne) SetFieldString(s string) *Test1SchemaUpdateOne {
 	tuo.mutation.SetFieldString(s)
@@ -395,6 +443,12 @@ func (tuo *Test1SchemaUpdateOne) sqlSave(ctx context.Context) (_node *Test1Schem
 			}
 		}
 	}
+	if value, ok := tuo.mutation.Version(); ok {
+		_spec.SetField(test1schema.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tuo.mutation.AddedVersion(); ok {
+		_spec.AddField(test1schema.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tuo.mutation.FieldString(); ok {
 		_spec.SetField(test1schema.FieldFieldString, field.TypeString, value)
 	}
@@ -17,6 +17,8 @@ type Test2Schema struct {
 	config `json:"-"`
 	// ID of the ent.
 	ID int `json:"id,omitempty"`
+	// Version holds the value of the "version" field.
+	Version int `json:"version,omitempty"`
 	// Name holds the value of the "name" field.
 	Name string `json:"name,omitempty"`
 	// Description holds the value of the "description" field.
@@ -45,7 +47,7 @@ func (*Test2Schema) scanValues(columns []string) ([]any, error) {
 			values[i] = new(sql.NullBool)
 		case test2schema.FieldPrice:
 			values[i] = new(sql.NullFloat64)
-		case test2schema.FieldID, test2schema.FieldQuantity:
+		case test2schema.FieldID, test2schema.FieldVersion, test2schema.FieldQuantity:
 			values[i] = new(sql.NullInt64)
 		case test2schema.FieldName, test2schema.FieldDescription, test2schema.FieldItemType:
 			values[i] = new(sql.NullString)
@@ -72,6 +74,12 @@ func (t *Test2Schema) assignValues(columns []string, values []any) error {
 				return fmt.Errorf("unexpected type %T for field id", value)
 			}
 			t.ID = int(value.Int64)
+		case test2schema.FieldVersion:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field version", values[i])
+			} else if value.Valid {
+				t.Version = int(value.Int64)
+			}
 		case test2schema.FieldName:
 			if value, ok := values[i].(*sql.NullString); !ok {
 				return fmt.Errorf("unexpected type %T for field name", values[i])
@@ -156,6 +164,9 @@ func (t *Test2Schema) String() string {
 	var builder strings.Builder
 	builder.WriteString("Test2Schema(")
 	builder.WriteString(fmt.Sprintf("id=%v, ", t.ID))
+	builder.WriteString("version=")
+	builder.WriteString(fmt.Sprintf("%v", t.Version))
+	builder.WriteString(", ")
 	builder.WriteString("name=")
 	builder.WriteString(t.Name)
 	builder.WriteString(", ")
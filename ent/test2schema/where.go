@@ -54,6 +54,11 @@ func IDLTE(id int) predicate.Test2Schema {
 	return predicate.Test2Schema(sql.FieldLTE(FieldID, id))
 }
 
+// Version applies equality check predicate on the "version" field. It's identical to VersionEQ.
+func Version(v int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldEQ(FieldVersion, v))
+}
+
 // Name applies equality check predicate on the "name" field. It's identical to NameEQ.
 func Name(v string) predicate.Test2Schema {
 	return predicate.Test2Schema(sql.FieldEQ(FieldName, v))
@@ -94,6 +99,46 @@ func ItemType(v string) predicate.Test2Schema {
 	return predicate.Test2Schema(sql.FieldEQ(FieldItemType, v))
 }
 
+// VersionEQ applies the EQ predicate on the "version" field.
+func VersionEQ(v int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldEQ(FieldVersion, v))
+}
+
+// VersionNEQ applies the NEQ predicate on the "version" field.
+func VersionNEQ(v int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldNEQ(FieldVersion, v))
+}
+
+// VersionIn applies the In predicate on the "version" field.
+func VersionIn(vs ...int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldIn(FieldVersion, vs...))
+}
+
+// VersionNotIn applies the NotIn predicate on the "version" field.
+func VersionNotIn(vs ...int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldNotIn(FieldVersion, vs...))
+}
+
+// VersionGT applies the GT predicate on the "version" field.
+func VersionGT(v int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldGT(FieldVersion, v))
+}
+
+// VersionGTE applies the GTE predicate on the "version" field.
+func VersionGTE(v int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldGTE(FieldVersion, v))
+}
+
+// VersionLT applies the LT predicate on the "version" field.
+func VersionLT(v int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldLT(FieldVersion, v))
+}
+
+// VersionLTE applies the LTE predicate on the "version" field.
+func VersionLTE(v int) predicate.Test2Schema {
+	return predicate.Test2Schema(sql.FieldLTE(FieldVersion, v))
+}
+
 // NameEQ applies the EQ predicate on the "name" field.
 func NameEQ(v string) predicate.Test2Schema {
 	return predicate.Test2Schema(sql.FieldEQ(FieldName, v))
@@ -20,6 +20,20 @@ type Test2SchemaCreate struct {
 	hooks    []Hook
 }
 
+// SetVersion sets the "version" field.
+func (tc *Test2SchemaCreate) SetVersion(i int) *Test2SchemaCreate {
+	tc.mutation.SetVersion(i)
+	return tc
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tc *Test2SchemaCreate) SetNillableVersion(i *int) *Test2SchemaCreate {
+	if i != nil {
+		tc.SetVersion(*i)
+	}
+	return tc
+}
+
 // SetName sets the "name" field.
 func (tc *Test2SchemaCreate) SetName(s string) *Test2SchemaCreate {
 	tc.mutation.SetName(s)
@@ -167,6 +181,10 @@ func (tc *Test2SchemaCreate) ExecX(ctx context.Context) {
 
 // defaults sets the default values of the builder before save.
 func (tc *Test2SchemaCreate) defaults() {
+	if _, ok := tc.mutation.Version(); !ok {
+		v := test2schema.DefaultVersion
+		tc.mutation.SetVersion(v)
+	}
 	if _, ok := tc.mutation.Name(); !ok {
 		v := test2schema.DefaultName
 		tc.mutation.SetName(v)
@@ -195,6 +213,9 @@ func (tc *Test2SchemaCreate) defaults() {
 
 // check runs all checks and user-defined validators on the builder.
 func (tc *Test2SchemaCreate) check() error {
+	if _, ok := tc.mutation.Version(); !ok {
+		return &ValidationError{Name: "version", err: errors.New(`ent: missing required field "Test2Schema.version"`)}
+	}
 	if _, ok := tc.mutation.Name(); !ok {
 		return &ValidationError{Name: "name", err: errors.New(`ent: missing required field "Test2Schema.name"`)}
 	}
@@ -239,6 +260,10 @@ func (tc *Test2SchemaCreate) createSpec() (*Test2Schema, *sqlgraph.CreateSpec) {
 		_node = &Test2Schema{config: tc.config}
 		_spec = sqlgraph.NewCreateSpec(test2schema.Table, sqlgraph.NewFieldSpec(test2schema.FieldID, field.TypeInt))
 	)
+	if value, ok := tc.mutation.Version(); ok {
+		_spec.SetField(test2schema.FieldVersion, field.TypeInt, value)
+		_node.Version = value
+	}
 	if value, ok := tc.mutation.Name(); ok {
 		_spec.SetField(test2schema.FieldName, field.TypeString, value)
 		_node.Name = value
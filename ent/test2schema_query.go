@@ -262,12 +262,12 @@ func (tq *Test2SchemaQuery) Clone() *Test2SchemaQuery {
 // Example:
 //
 //	var v []struct {
-//		Name string `json:"name,omitempty"`
+//		Version int `json:"version,omitempty"`
 //		Count int `json:"count,omitempty"`
 //	}
 //
 //	client.Test2Schema.Query().
-//		GroupBy(test2schema.FieldName).
+//		GroupBy(test2schema.FieldVersion).
 //		Aggregate(ent.Count()).
 //		Scan(ctx, &v)
 func (tq *Test2SchemaQuery) GroupBy(field string, fields ...string) *Test2SchemaGroupBy {
@@ -285,11 +285,11 @@ func (tq *Test2SchemaQuery) GroupBy(field string, fields ...string) *Test2Schema
 // Example:
 //
 //	var v []struct {
-//		Name string `json:"name,omitempty"`
+//		Version int `json:"version,omitempty"`
 //	}
 //
 //	client.Test2Schema.Query().
-//		Select(test2schema.FieldName).
+//		Select(test2schema.FieldVersion).
 //		Scan(ctx, &v)
 func (tq *Test2SchemaQuery) Select(fields ...string) *Test2SchemaSelect {
 	tq.ctx.Fields = append(tq.ctx.Fields, fields...)
@@ -28,6 +28,27 @@ func (tu *Test2SchemaUpdate) Where(ps ...predicate.Test2Schema) *Test2SchemaUpda
 	return tu
 }
 
+// SetVersion sets the "version" field.
+func (tu *Test2SchemaUpdate) SetVersion(i int) *Test2SchemaUpdate {
+	tu.mutation.ResetVersion()
+	tu.mutation.SetVersion(i)
+	return tu
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tu *Test2SchemaUpdate) SetNillableVersion(i *int) *Test2SchemaUpdate {
+	if i != nil {
+		tu.SetVersion(*i)
+	}
+	return tu
+}
+
+// AddVersion adds i to the "version" field.
+func (tu *Test2SchemaUpdate) AddVersion(i int) *Test2SchemaUpdate {
+	tu.mutation.AddVersion(i)
+	return tu
+}
+
 // SetName sets the "name" field.
 func (tu *Test2SchemaUpdate) SetName(s string) *Test2SchemaUpdate {
 	tu.mutation.SetName(s)
@@ -208,6 +229,12 @@ func (tu *Test2SchemaUpdate) sqlSave(ctx context.Context) (n int, err error) {
 			}
 		}
 	}
+	if value, ok := tu.mutation.Version(); ok {
+		_spec.SetField(test2schema.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tu.mutation.AddedVersion(); ok {
+		_spec.AddField(test2schema.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tu.mutation.Name(); ok {
 		_spec.SetField(test2schema.FieldName, field.TypeString, value)
 	}
@@ -264,6 +291,27 @@ type Test2SchemaUpdateOne struct {
 	mutation *Test2SchemaMutation
 }
 
+// SetVersion sets the "version" field.
+func (tuo *Test2SchemaUpdateOne) SetVersion(i int) *Test2SchemaUpdateOne {
+	tuo.mutation.ResetVersion()
+	tuo.mutation.SetVersion(i)
+	return tuo
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tuo *Test2SchemaUpdateOne) SetNillableVersion(i *int) *Test2SchemaUpdateOne {
+	if i != nil {
+		tuo.SetVersion(*i)
+	}
+	return tuo
+}
+
+// AddVersion adds i to the "version" field.
+func (tuo *Test2SchemaUpdateOne) AddVersion(i int) *Test2SchemaUpdateOne {
+	tuo.mutation.AddVersion(i)
+	return tuo
+}
+
 // SetName sets the "name" field.
 func (tuo *Test2SchemaUpdateOne) SetName(s string) *Test2SchemaUpdateOne {
 	tuo.mutation.SetName(s)
@@ -474,6 +522,12 @@ func (tuo *Test2SchemaUpdateOne) sqlSave(ctx context.Context) (_node *Test2Schem
 			}
 		}
 	}
+	if value, ok := tuo.mutation.Version(); ok {
+		_spec.SetField(test2schema.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tuo.mutation.AddedVersion(); ok {
+		_spec.AddField(test2schema.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tuo.mutation.Name(); ok {
 		_spec.SetField(test2schema.FieldName, field.TypeString, value)
 	}
@@ -17,6 +17,8 @@ type Test3Schema struct {
 	config `json:"-"`
 	// ID of the ent.
 	ID int `json:"id,omitempty"`
+	// Version holds the value of the "version" field.
+	Version int `json:"version,omitempty"`
 	// Sku holds the value of the "sku" field.
 	Sku string `json:"sku,omitempty"`
 	// ProductName holds the value of the "product_name" field.
@@ -51,7 +53,7 @@ func (*Test3Schema) scanValues(columns []string) ([]any, error) {
 			values[i] = new(sql.NullBool)
 		case test3schema.FieldCostPrice, test3schema.FieldRetailPrice:
 			values[i] = new(sql.NullFloat64)
-		case test3schema.FieldID, test3schema.FieldStockCount:
+		case test3schema.FieldID, test3schema.FieldVersion, test3schema.FieldStockCount:
 			values[i] = new(sql.NullInt64)
 		case test3schema.FieldSku, test3schema.FieldProductName, test3schema.FieldShortDescription, test3schema.FieldFullDescription, test3schema.FieldTags:
 			values[i] = new(sql.NullString)
@@ -78,6 +80,12 @@ func (t *Test3Schema) assignValues(columns []string, values []any) error {
 				return fmt.Errorf("unexpected type %T for field id", value)
 			}
 			t.ID = int(value.Int64)
+		case test3schema.FieldVersion:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field version", values[i])
+			} else if value.Valid {
+				t.Version = int(value.Int64)
+			}
 		case test3schema.FieldSku:
 			if value, ok := values[i].(*sql.NullString); !ok {
 				return fmt.Errorf("unexpected type %T for field sku", values[i])
@@ -180,6 +188,9 @@ func (t *Test3Schema) String() string {
 	var builder strings.Builder
 	builder.WriteString("Test3Schema(")
 	builder.WriteString(fmt.Sprintf("id=%v, ", t.ID))
+	builder.WriteString("version=")
+	builder.WriteString(fmt.Sprintf("%v", t.Version))
+	builder.WriteString(", ")
 	builder.WriteString("sku=")
 	builder.WriteString(t.Sku)
 	builder.WriteString(", ")
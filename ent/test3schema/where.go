@@ -54,6 +54,11 @@ func IDLTE(id int) predicate.Test3Schema {
 	return predicate.Test3Schema(sql.FieldLTE(FieldID, id))
 }
 
+// Version applies equality check predicate on the "version" field. It's identical to VersionEQ.
+func Version(v int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldEQ(FieldVersion, v))
+}
+
 // Sku applies equality check predicate on the "sku" field. It's identical to SkuEQ.
 func Sku(v string) predicate.Test3Schema {
 	return predicate.Test3Schema(sql.FieldEQ(FieldSku, v))
@@ -109,6 +114,46 @@ func Tags(v string) predicate.Test3Schema {
 	return predicate.Test3Schema(sql.FieldEQ(FieldTags, v))
 }
 
+// VersionEQ applies the EQ predicate on the "version" field.
+func VersionEQ(v int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldEQ(FieldVersion, v))
+}
+
+// VersionNEQ applies the NEQ predicate on the "version" field.
+func VersionNEQ(v int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldNEQ(FieldVersion, v))
+}
+
+// VersionIn applies the In predicate on the "version" field.
+func VersionIn(vs ...int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldIn(FieldVersion, vs...))
+}
+
+// VersionNotIn applies the NotIn predicate on the "version" field.
+func VersionNotIn(vs ...int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldNotIn(FieldVersion, vs...))
+}
+
+// VersionGT applies the GT predicate on the "version" field.
+func VersionGT(v int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldGT(FieldVersion, v))
+}
+
+// VersionGTE applies the GTE predicate on the "version" field.
+func VersionGTE(v int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldGTE(FieldVersion, v))
+}
+
+// VersionLT applies the LT predicate on the "version" field.
+func VersionLT(v int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldLT(FieldVersion, v))
+}
+
+// VersionLTE applies the LTE predicate on the "version" field.
+func VersionLTE(v int) predicate.Test3Schema {
+	return predicate.Test3Schema(sql.FieldLTE(FieldVersion, v))
+}
+
 // SkuEQ applies the EQ predicate on the "sku" field.
 func SkuEQ(v string) predicate.Test3Schema {
 	return predicate.Test3Schema(sql.FieldEQ(FieldSku, v))
@@ -20,6 +20,20 @@ type Test3SchemaCreate struct {
 	hooks    []Hook
 }
 
+// SetVersion sets the "version" field.
+func (tc *Test3SchemaCreate) SetVersion(i int) *Test3SchemaCreate {
+	tc.mutation.SetVersion(i)
+	return tc
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tc *Test3SchemaCreate) SetNillableVersion(i *int) *Test3SchemaCreate {
+	if i != nil {
+		tc.SetVersion(*i)
+	}
+	return tc
+}
+
 // SetSku sets the "sku" field.
 func (tc *Test3SchemaCreate) SetSku(s string) *Test3SchemaCreate {
 	tc.mutation.SetSku(s)
@@ -201,6 +215,10 @@ func (tc *Test3SchemaCreate) ExecX(ctx context.Context) {
 
 // defaults sets the default values of the builder before save.
 func (tc *Test3SchemaCreate) defaults() {
+	if _, ok := tc.mutation.Version(); !ok {
+		v := test3schema.DefaultVersion
+		tc.mutation.SetVersion(v)
+	}
 	if _, ok := tc.mutation.ProductName(); !ok {
 		v := test3schema.DefaultProductName
 		tc.mutation.SetProductName(v)
@@ -225,6 +243,9 @@ func (tc *Test3SchemaCreate) defaults() {
 
 // check runs all checks and user-defined validators on the builder.
 func (tc *Test3SchemaCreate) check() error {
+	if _, ok := tc.mutation.Version(); !ok {
+		return &ValidationError{Name: "version", err: errors.New(`ent: missing required field "Test3Schema.version"`)}
+	}
 	if _, ok := tc.mutation.Sku(); !ok {
 		return &ValidationError{Name: "sku", err: errors.New(`ent: missing required field "Test3Schema.sku"`)}
 	}
@@ -274,6 +295,10 @@ func (tc *Test3SchemaCreate) createSpec() (*Test3Schema, *sqlgraph.CreateSpec) {
 		_node = &Test3Schema{config: tc.config}
 		_spec = sqlgraph.NewCreateSpec(test3schema.Table, sqlgraph.NewFieldSpec(test3schema.FieldID, field.TypeInt))
 	)
+	if value, ok := tc.mutation.Version(); ok {
+		_spec.SetField(test3schema.FieldVersion, field.TypeInt, value)
+		_node.Version = value
+	}
 	if value, ok := tc.mutation.Sku(); ok {
 		_spec.SetField(test3schema.FieldSku, field.TypeString, value)
 		_node.Sku = value
@@ -262,12 +262,12 @@ func (tq *Test3SchemaQuery) Clone() *Test3SchemaQuery {
 // Example:
 //
 //	var v []struct {
-//		Sku string `json:"sku,omitempty"`
+//		Version int `json:"version,omitempty"`
 //		Count int `json:"count,omitempty"`
 //	}
 //
 //	client.Test3Schema.Query().
-//		GroupBy(test3schema.FieldSku).
+//		GroupBy(test3schema.FieldVersion).
 //		Aggregate(ent.Count()).
 //		Scan(ctx, &v)
 func (tq *Test3SchemaQuery) GroupBy(field string, fields ...string) *Test3SchemaGroupBy {
@@ -285,11 +285,11 @@ func (tq *Test3SchemaQuery) GroupBy(field string, fields ...string) *Test3Schema
 // Example:
 //
 //	var v []struct {
-//		Sku string `json:"sku,omitempty"`
+//		Version int `json:"version,omitempty"`
 //	}
 //
 //	client.Test3Schema.Query().
-//		Select(test3schema.FieldSku).
+//		Select(test3schema.FieldVersion).
 //		Scan(ctx, &v)
 func (tq *Test3SchemaQuery) Select(fields ...string) *Test3SchemaSelect {
 	tq.ctx.Fields = append(tq.ctx.Fields, fields...)
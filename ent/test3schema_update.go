@@ -28,6 +28,27 @@ func (tu *Test3SchemaUpdate) Where(ps ...predicate.Test3Schema) *Test3SchemaUpda
 	return tu
 }
 
+// SetVersion sets the "version" field.
+func (tu *Test3SchemaUpdate) SetVersion(i int) *Test3SchemaUpdate {
+	tu.mutation.ResetVersion()
+	tu.mutation.SetVersion(i)
+	return tu
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tu *Test3SchemaUpdate) SetNillableVersion(i *int) *Test3SchemaUpdate {
+	if i != nil {
+		tu.SetVersion(*i)
+	}
+	return tu
+}
+
+// AddVersion adds i to the "version" field.
+func (tu *Test3SchemaUpdate) AddVersion(i int) *Test3SchemaUpdate {
+	tu.mutation.AddVersion(i)
+	return tu
+}
+
 // SetSku sets the "sku" field.
 func (tu *Test3SchemaUpdate) SetSku(s string) *Test3SchemaUpdate {
 	tu.mutation.SetSku(s)
@@ -287,6 +308,12 @@ func (tu *Test3SchemaUpdate) sqlSave(ctx context.Context) (n int, err error) {
 			}
 		}
 	}
+	if value, ok := tu.mutation.Version(); ok {
+		_spec.SetField(test3schema.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tu.mutation.AddedVersion(); ok {
+		_spec.AddField(test3schema.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tu.mutation.Sku(); ok {
 		_spec.SetField(test3schema.FieldSku, field.TypeString, value)
 	}
@@ -364,6 +391,27 @@ type Test3SchemaUpdateOne struct {
 	mutation *Test3SchemaMutation
 }
 
+// SetVersion sets the "version" field.
+func (tuo *Test3SchemaUpdateOne) SetVersion(i int) *Test3SchemaUpdateOne {
+	tuo.mutation.ResetVersion()
+	tuo.mutation.SetVersion(i)
+	return tuo
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tuo *Test3SchemaUpdateOne) SetNillableVersion(i *int) *Test3SchemaUpdateOne {
+	if i != nil {
+		tuo.SetVersion(*i)
+	}
+	return tuo
+}
+
+// AddVersion adds i to the "version" field.
+func (tuo *Test3SchemaUpdateOne) AddVersion(i int) *Test3SchemaUpdateOne {
+	tuo.mutation.AddVersion(i)
+	return tuo
+}
+
 // SetSku sets the "sku" field.
 func (tuo *Test3SchemaUpdateOne) SetSku(s string) *Test3SchemaUpdateOne {
 	tuo.mutation.SetSku(s)
@@ -653,6 +701,12 @@ func (tuo *Test3SchemaUpdateOne) sqlSave(ctx context.Context) (_node *Test3Schem
 			}
 		}
 	}
+	if value, ok := tuo.mutation.Version(); ok {
+		_spec.SetField(test3schema.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tuo.mutation.AddedVersion(); ok {
+		_spec.AddField(test3schema.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tuo.mutation.Sku(); ok {
 		_spec.SetField(test3schema.FieldSku, field.TypeString, value)
 	}
@@ -20,6 +20,20 @@ type TransactionCreate struct {
 	hooks    []Hook
 }
 
+// SetVersion sets the "version" field.
+func (tc *TransactionCreate) SetVersion(i int) *TransactionCreate {
+	tc.mutation.SetVersion(i)
+	return tc
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tc *TransactionCreate) SetNillableVersion(i *int) *TransactionCreate {
+	if i != nil {
+		tc.SetVersion(*i)
+	}
+	return tc
+}
+
 // SetDate sets the "date" field.
 func (tc *TransactionCreate) SetDate(t time.Time) *TransactionCreate {
 	tc.mutation.SetDate(t)
@@ -63,6 +77,7 @@ func (tc *TransactionCreate) Mutation() *TransactionMutation {
 
 // Save creates the Transaction in the database.
 func (tc *TransactionCreate) Save(ctx context.Context) (*Transaction, error) {
+	tc.defaults()
 	return withHooks(ctx, tc.sqlSave, tc.mutation, tc.hooks)
 }
 
@@ -88,8 +103,19 @@ func (tc *TransactionCreate) ExecX(ctx context.Context) {
 	}
 }
 
+// defaults sets the default values of the builder before save.
+func (tc *TransactionCreate) defaults() {
+	if _, ok := tc.mutation.Version(); !ok {
+		v := transaction.DefaultVersion
+		tc.mutation.SetVersion(v)
+	}
+}
+
 // check runs all checks and user-defined validators on the builder.
 func (tc *TransactionCreate) check() error {
+	if _, ok := tc.mutation.Version(); !ok {
+		return &ValidationError{Name: "version", err: errors.New(`ent: missing required field "Transaction.version"`)}
+	}
 	if _, ok := tc.mutation.Date(); !ok {
 		return &ValidationError{Name: "date", err: errors.New(`ent: missing required field "Transaction.date"`)}
 	}
@@ -134,6 +160,10 @@ func (tc *TransactionCreate) createSpec() (*Transaction, *sqlgraph.CreateSpec) {
 		_node = &Transaction{config: tc.config}
 		_spec = sqlgraph.NewCreateSpec(transaction.Table, sqlgraph.NewFieldSpec(transaction.FieldID, field.TypeInt))
 	)
+	if value, ok := tc.mutation.Version(); ok {
+		_spec.SetField(transaction.FieldVersion, field.TypeInt, value)
+		_node.Version = value
+	}
 	if value, ok := tc.mutation.Date(); ok {
 		_spec.SetField(transaction.FieldDate, field.TypeTime, value)
 		_node.Date = value
@@ -179,6 +209,7 @@ func (tcb *TransactionCreateBulk) Save(ctx context.Context) ([]*Transaction, err
 	for i := range tcb.builders {
 		func(i int, root context.Context) {
 			builder := tcb.builders[i]
+			builder.defaults()
 			var mut Mutator = MutateFunc(func(ctx context.Context, m Mutation) (Value, error) {
 				mutation, ok := m.(*TransactionMutation)
 				if !ok {
@@ -28,6 +28,27 @@ func (tu *TransactionUpdate) Where(ps ...predicate.Transaction) *TransactionUpda
 	return tu
 }
 
+// SetVersion sets the "version" field.
+func (tu *TransactionUpdate) SetVersion(i int) *TransactionUpdate {
+	tu.mutation.ResetVersion()
+	tu.mutation.SetVersion(i)
+	return tu
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tu *TransactionUpdate) SetNillableVersion(i *int) *TransactionUpdate {
+	if i != nil {
+		tu.SetVersion(*i)
+	}
+	return tu
+}
+
+// AddVersion adds i to the "version" field.
+func (tu *TransactionUpdate) AddVersion(i int) *TransactionUpdate {
+	tu.mutation.AddVersion(i)
+	return tu
+}
+
 // SetDate sets the "date" field.
 func (tu *TransactionUpdate) SetDate(t time.Time) *TransactionUpdate {
 	tu.mutation.SetDate(t)
@@ -160,6 +181,12 @@ func (tu *TransactionUpdate) sqlSave(ctx context.Context) (n int, err error) {
 			}
 		}
 	}
+	if value, ok := tu.mutation.Version(); ok {
+		_spec.SetField(transaction.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tu.mutation.AddedVersion(); ok {
+		_spec.AddField(transaction.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tu.mutation.Date(); ok {
 		_spec.SetField(transaction.FieldDate, field.TypeTime, value)
 	}
@@ -201,6 +228,27 @@ type TransactionUpdateOne struct {
 	mutation *TransactionMutation
 }
 
+// SetVersion sets the "version" field.
+func (tuo *TransactionUpdateOne) SetVersion(i int) *TransactionUpdateOne {
+	tuo.mutation.ResetVersion()
+	tuo.mutation.SetVersion(i)
+	return tuo
+}
+
+// SetNillableVersion sets the "version" field if the given value is not nil.
+func (tuo *TransactionUpdateOne) SetNillableVersion(i *int) *TransactionUpdateOne {
+	if i != nil {
+		tuo.SetVersion(*i)
+	}
+	return tuo
+}
+
+// AddVersion adds i to the "version" field.
+func (tuo *TransactionUpdateOne) AddVersion(i int) *TransactionUpdateOne {
+	tuo.mutation.AddVersion(i)
+	return tuo
+}
+
 // SetDate sets the "date" field.
 func (tuo *TransactionUpdateOne) SetDate(t time.Time) *TransactionUpdateOne {
 	tuo.mutation.SetDate(t)
@@ -363,6 +411,12 @@ func (tuo *TransactionUpdateOne) sqlSave(ctx context.Context) (_node *Transactio
 			}
 		}
 	}
+	if value, ok := tuo.mutation.Version(); ok {
+		_spec.SetField(transaction.FieldVersion, field.TypeInt, value)
+	}
+	if value, ok := tuo.mutation.AddedVersion(); ok {
+		_spec.AddField(transaction.FieldVersion, field.TypeInt, value)
+	}
 	if value, ok := tuo.mutation.Date(); ok {
 		_spec.SetField(transaction.FieldDate, field.TypeTime, value)
 	}
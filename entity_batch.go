@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"transaction-filter-backend/ent"
+	"transaction-filter-backend/filterast"
+)
+
+// Types of batch changes, as DataGrid's batch edit mode names them.
+const (
+	changeInsert = "insert"
+	changeUpdate = "update"
+	changeRemove = "remove"
+)
+
+// Outcomes of a batch change reported in batchResult.Status.
+const (
+	batchApplied    = "applied"     // committed with the batch
+	batchFailed     = "failed"      // the change is invalid or failed, and the batch was not committed
+	batchRolledBack = "rolled_back" // applied, then undone because a later change failed
+	batchSkipped    = "skipped"     // not run because another change is invalid or failed
+)
+
+// batchChange is one item of DataGrid's batch changes: an insert of data,
+// or an update of data or removal of the record with key, on an entity.
+// Updates and removals must give the version of the record they are based
+// on, as read with it.
+type batchChange struct {
+	Entity  string                 `json:"entity"`
+	Type    string                 `json:"type"`
+	Key     interface{}            `json:"key"`
+	Version interface{}            `json:"version"`
+	Data    map[string]interface{} `json:"data"`
+}
+
+// batchKey is the record a checked change applies to: its id and the
+// version the client read, both 0 for an insert.
+type batchKey struct {
+	id, version int
+}
+
+// batchResult reports the outcome of a batch change. Key is the key of the
+// record changed, for an insert the new record's, and Data the record as
+// saved, with its new version. Error and Errors describe why a failed
+// change failed; on a version conflict Current is the record's current
+// state.
+type batchResult struct {
+	Index   int                        `json:"index"`
+	Entity  string                     `json:"entity"`
+	Type    string                     `json:"type"`
+	Key     interface{}                `json:"key,omitempty"`
+	Status  string                     `json:"status"`
+	Data    map[string]interface{}     `json:"data,omitempty"`
+	Error   string                     `json:"error,omitempty"`
+	Errors  filterast.ValidationErrors `json:"errors,omitempty"`
+	Current map[string]interface{}     `json:"current,omitempty"`
+}
+
+// fail marks the change failed because of err.
+func (r *batchResult) fail(err error) {
+	r.Status, r.Error = batchFailed, err.Error()
+	var errs filterast.ValidationErrors
+	if errors.As(err, &errs) {
+		r.Error, r.Errors = "invalid values", errs
+	}
+}
+
+// batchSaveHandler answers POST /entities/batch with {"changes": [...]}: it
+// applies the changes in order in a single ent transaction and commits them
+// only if every one succeeds. Every change is checked against its entity's
+// schema before any runs, so all invalid changes are reported at once with
+// a 400. A change failing in the database rolls the batch back and answers
+// with the status the change alone would have had, e.g. 409 for a duplicate
+// unique value or a record changed since the client read it. The response
+// lists a batchResult per change either way.
+func batchSaveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var requestBody struct {
+		Changes []batchChange `json:"changes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	changes := requestBody.Changes
+	results := make([]batchResult, len(changes))
+	keys := make([]batchKey, len(changes))
+	valid := true
+	for i, change := range changes {
+		results[i] = batchResult{Index: i, Entity: change.Entity, Type: change.Type, Key: change.Key, Status: batchSkipped}
+		key, err := checkBatchChange(change)
+		if err != nil {
+			results[i].fail(err)
+			valid = false
+		}
+		keys[i] = key
+	}
+	if !valid {
+		writeBatchResponse(w, http.StatusBadRequest, false, results)
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		log.Printf("Backend: Error starting batch transaction: %v", err)
+		http.Error(w, fmt.Sprintf("Error starting transaction: %v", err), http.StatusInternalServerError)
+		return
+	}
+	for i, change := range changes {
+		id, err := applyBatchChange(ctx, tx.Client(), change, keys[i])
+		if err != nil {
+			if errRollback := tx.Rollback(); errRollback != nil {
+				log.Printf("Backend: Error rolling back batch: %v", errRollback)
+			}
+			for j := range results[:i] {
+				results[j].Status = batchRolledBack
+			}
+			results[i].fail(err)
+			if errors.As(err, new(*versionConflictError)) {
+				adapter, _ := GetAdapter(change.Entity)
+				results[i].Current, _ = entityRecord(ctx, change.Entity, adapter, keys[i].id)
+			}
+			writeBatchResponse(w, entityErrorStatus(err), false, results)
+			return
+		}
+		keys[i].id = id
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Backend: Error committing batch: %v", err)
+		for i := range results {
+			results[i].Status = batchRolledBack
+		}
+		writeBatchResponse(w, entityErrorStatus(err), false, results)
+		return
+	}
+	for i, change := range changes {
+		results[i].Status, results[i].Key = batchApplied, keys[i].id
+		if change.Type == changeRemove {
+			continue
+		}
+		adapter, _ := GetAdapter(change.Entity)
+		if results[i].Data, err = entityRecord(ctx, change.Entity, adapter, keys[i].id); err != nil {
+			log.Printf("Backend: Error reading record %d of %s after batch: %v", keys[i].id, change.Entity, err)
+		}
+	}
+	writeBatchResponse(w, http.StatusOK, true, results)
+}
+
+// checkBatchChange checks a change's entity, type, key, version and data
+// without running it, and returns the record it changes.
+func checkBatchChange(change batchChange) (batchKey, error) {
+	fail := func(path, format string, args ...interface{}) (batchKey, error) {
+		return batchKey{}, filterast.ValidationErrors{{Path: path, Code: filterast.CodeInvalidValue, Message: fmt.Sprintf(format, args...)}}
+	}
+	adapter, err := GetAdapter(change.Entity)
+	if _, hasTable := entityTables[strings.ToLower(change.Entity)]; err != nil || !hasTable {
+		return fail("entity", "no adapter for entity '%s'", change.Entity)
+	}
+	var key batchKey
+	switch change.Type {
+	case changeInsert:
+	case changeUpdate, changeRemove:
+		id, err := filterast.CoerceValue("int", change.Key)
+		if err != nil {
+			return fail("key", "invalid key %v: %v", change.Key, err)
+		}
+		version, err := filterast.CoerceValue("int", change.Version)
+		if err != nil || version.(int) < 1 {
+			return fail("version", "the version of the record is required, got %v", change.Version)
+		}
+		key = batchKey{id: id.(int), version: version.(int)}
+	default:
+		return fail("type", "change type must be insert, update or remove, got '%s'", change.Type)
+	}
+	if change.Type != changeRemove {
+		if _, err := entityValues(adapter.Fields(), change.Data, change.Type == changeInsert); err != nil {
+			return batchKey{}, err
+		}
+	}
+	return key, nil
+}
+
+// applyBatchChange runs a checked change with c, a transaction's client,
+// and returns the key of the record changed.
+func applyBatchChange(ctx context.Context, c *ent.Client, change batchChange, key batchKey) (int, error) {
+	adapter, err := GetAdapter(change.Entity)
+	if err != nil {
+		return 0, err
+	}
+	switch change.Type {
+	case changeInsert:
+		return saveEntity(ctx, c, change.Entity, adapter.Fields(), 0, 0, true, change.Data)
+	case changeUpdate:
+		return saveEntity(ctx, c, change.Entity, adapter.Fields(), key.id, key.version, false, change.Data)
+	default:
+		return key.id, deleteEntity(ctx, c, change.Entity, key.id, key.version)
+	}
+}
+
+func writeBatchResponse(w http.ResponseWriter, status int, committed bool, results []batchResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Committed bool          `json:"committed"`
+		Results   []batchResult `json:"results"`
+	}{committed, results})
+}
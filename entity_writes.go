@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"transaction-filter-backend/ent"
+	"transaction-filter-backend/ent/test1schema"
+	"transaction-filter-backend/ent/test2schema"
+	"transaction-filter-backend/ent/test3schema"
+	"transaction-filter-backend/ent/transaction"
+	"transaction-filter-backend/filterast"
+	"transaction-filter-backend/schematool"
+
+	"entgo.io/ent/dialect/sql"
+)
+
+// errRecordNotFound is returned when no record of an entity has the key.
+var errRecordNotFound = errors.New("record not found")
+
+// entityWriteRequest is the body of POST and PUT on /entities/, DevExtreme
+// CustomStore's insert(values) and update(key, values) arguments. Key is
+// optional, as the key is part of the URL, but must match it when sent.
+type entityWriteRequest struct {
+	Key    interface{}            `json:"key"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// entityRecordHandler serves the records of ent entities for DataGrid
+// editing:
+//
+//	POST   /entities/{entity}        create a record from {"values"}, 201
+//	GET    /entities/{entity}/{key}  read a record
+//	PUT    /entities/{entity}/{key}  update the given {"values"} of a record
+//	DELETE /entities/{entity}/{key}  delete a record, 204
+//
+// Records are returned like /filter returns them, with their version as
+// ETag. PUT and DELETE must send it back as If-Match (428 otherwise), and
+// are refused with a 409 and the record's current state when the record
+// has changed since. "If-Match: *" writes whatever the record's version.
+func entityRecordHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/entities/"), "/"), "/")
+	entity := strings.ToLower(parts[0])
+	adapter, err := GetAdapter(entity)
+	if _, hasTable := entityTables[entity]; err != nil || !hasTable {
+		http.Error(w, fmt.Sprintf("No adapter for entity '%s'", parts[0]), http.StatusNotFound)
+		return
+	}
+	ctx := r.Context()
+	if len(parts) == 1 {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var requestBody entityWriteRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		id, err := saveEntity(ctx, client, entity, adapter.Fields(), 0, 0, true, requestBody.Values)
+		if err != nil {
+			writeEntityError(w, err)
+			return
+		}
+		writeEntityRecord(w, ctx, entity, adapter, id, http.StatusCreated)
+		return
+	}
+	if len(parts) != 2 {
+		http.NotFound(w, r)
+		return
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid key '%s'", parts[1]), http.StatusBadRequest)
+		return
+	}
+	if r.Method == http.MethodGet {
+		writeEntityRecord(w, ctx, entity, adapter, id, http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	version, ok := ifMatchVersion(r)
+	if !ok {
+		http.Error(w, "If-Match header with the record's ETag is required", http.StatusPreconditionRequired)
+		return
+	}
+	fail := func(err error) {
+		if errors.As(err, new(*versionConflictError)) {
+			writeEntityRecord(w, ctx, entity, adapter, id, http.StatusConflict)
+			return
+		}
+		writeEntityError(w, err)
+	}
+	if r.Method == http.MethodDelete {
+		if err := deleteEntity(ctx, client, entity, id, version); err != nil {
+			fail(err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	var requestBody entityWriteRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if requestBody.Key != nil {
+		if key, err := filterast.CoerceValue("int", requestBody.Key); err != nil || key != id {
+			http.Error(w, fmt.Sprintf("Key %v does not match the URL", requestBody.Key), http.StatusBadRequest)
+			return
+		}
+	}
+	if _, err := saveEntity(ctx, client, entity, adapter.Fields(), id, version, false, requestBody.Values); err != nil {
+		fail(err)
+		return
+	}
+	writeEntityRecord(w, ctx, entity, adapter, id, http.StatusOK)
+}
+
+// anyVersion is the version of a write that applies to the record whatever
+// its version, as asked for with "If-Match: *". Record versions start at 1.
+const anyVersion = 0
+
+// ifMatchVersion reads the version of the record a write is based on from
+// the If-Match header, which holds the ETag the record was read with,
+// quoted or not and strong or weak, or "*" for anyVersion.
+func ifMatchVersion(r *http.Request) (int, bool) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "*" {
+		return anyVersion, true
+	}
+	tag = strings.TrimSpace(strings.TrimPrefix(tag, "W/"))
+	version, err := strconv.Atoi(strings.Trim(tag, `"`))
+	return version, err == nil && version > 0
+}
+
+// versionETag is the ETag of a record at version.
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// writeEntityRecord writes the record id of entity with status and its
+// version as ETag, or the error reading it.
+func writeEntityRecord(w http.ResponseWriter, ctx context.Context, entity string, adapter EntityAdapter, id int, status int) {
+	record, err := entityRecord(ctx, entity, adapter, id)
+	if err != nil {
+		writeEntityError(w, err)
+		return
+	}
+	if version, ok := record["version"].(float64); ok {
+		w.Header().Set("ETag", versionETag(int(version)))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(record)
+}
+
+// entityRecord reads the record id of entity in its JSON form, computed
+// fields included.
+func entityRecord(ctx context.Context, entity string, adapter EntityAdapter, id int) (map[string]interface{}, error) {
+	results, err := queryEntities(ctx, entity, adapter, sql.EQ("id", id), func(*sql.Selector) {}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	records, err := recordMaps(results)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errRecordNotFound
+	}
+	return records[0], nil
+}
+
+// writeEntityError reports a failed read or write with the status of
+// entityErrorStatus: invalid values with the list of problems, a missing
+// record or violated constraint with a message.
+func writeEntityError(w http.ResponseWriter, err error) {
+	var validationErrs filterast.ValidationErrors
+	switch status := entityErrorStatus(err); {
+	case errors.As(err, &validationErrs):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(filterErrorResponse{Error: "invalid values", Errors: validationErrs})
+	case status == http.StatusNotFound:
+		http.Error(w, "Record not found", status)
+	case status == http.StatusInternalServerError:
+		log.Printf("Backend: Error writing record: %v", err)
+		http.Error(w, fmt.Sprintf("Error writing record: %v", err), status)
+	default:
+		http.Error(w, err.Error(), status)
+	}
+}
+
+// entityErrorStatus returns the HTTP status of a failed read or write: 400
+// for invalid values or a value rejected by the ent schema's validators, 404
+// for a missing record, 409 for a version conflict or a violated constraint
+// such as a duplicate unique value and 500 for anything else.
+func entityErrorStatus(err error) int {
+	var validationErrs filterast.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs), ent.IsValidationError(err):
+		return http.StatusBadRequest
+	case errors.Is(err, errRecordNotFound), ent.IsNotFound(err):
+		return http.StatusNotFound
+	case errors.As(err, new(*versionConflictError)), ent.IsConstraintError(err):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// entityWriter creates, updates and deletes the records of one entity
+// through the generated builders of an ent client, which may be the client
+// of a transaction. Mutations are run with the client's Mutate. Updates and
+// deletes only match the record at the version the client read, unless it
+// is anyVersion, and updates increment it, see the VersionMixin of the ent
+// schemas.
+type entityWriter struct {
+	create  func() ent.Mutation
+	update  func(id, version int) ent.Mutation
+	delete  func(ctx context.Context, id, version int) (int, error)
+	version func(ctx context.Context, id int) (int, error)
+}
+
+func newEntityWriter(c *ent.Client, entity string) (entityWriter, error) {
+	switch strings.ToLower(entity) {
+	case "transaction":
+		return entityWriter{
+			create: func() ent.Mutation { return c.Transaction.Create().Mutation() },
+			update: func(id, version int) ent.Mutation {
+				update := c.Transaction.UpdateOneID(id).AddVersion(1)
+				if version != anyVersion {
+					update.Where(transaction.Version(version))
+				}
+				return update.Mutation()
+			},
+			delete: func(ctx context.Context, id, version int) (int, error) {
+				del := c.Transaction.Delete().Where(transaction.ID(id))
+				if version != anyVersion {
+					del.Where(transaction.Version(version))
+				}
+				return del.Exec(ctx)
+			},
+			version: func(ctx context.Context, id int) (int, error) {
+				return c.Transaction.Query().Where(transaction.ID(id)).Select(transaction.FieldVersion).Int(ctx)
+			},
+		}, nil
+	case "test1schema":
+		return entityWriter{
+			create: func() ent.Mutation { return c.Test1Schema.Create().Mutation() },
+			update: func(id, version int) ent.Mutation {
+				update := c.Test1Schema.UpdateOneID(id).AddVersion(1)
+				if version != anyVersion {
+					update.Where(test1schema.Version(version))
+				}
+				return update.Mutation()
+			},
+			delete: func(ctx context.Context, id, version int) (int, error) {
+				del := c.Test1Schema.Delete().Where(test1schema.ID(id))
+				if version != anyVersion {
+					del.Where(test1schema.Version(version))
+				}
+				return del.Exec(ctx)
+			},
+			version: func(ctx context.Context, id int) (int, error) {
+				return c.Test1Schema.Query().Where(test1schema.ID(id)).Select(test1schema.FieldVersion).Int(ctx)
+			},
+		}, nil
+	case "test2schema":
+		return entityWriter{
+			create: func() ent.Mutation { return c.Test2Schema.Create().Mutation() },
+			update: func(id, version int) ent.Mutation {
+				update := c.Test2Schema.UpdateOneID(id).AddVersion(1)
+				if version != anyVersion {
+					update.Where(test2schema.Version(version))
+				}
+				return update.Mutation()
+			},
+			delete: func(ctx context.Context, id, version int) (int, error) {
+				del := c.Test2Schema.Delete().Where(test2schema.ID(id))
+				if version != anyVersion {
+					del.Where(test2schema.Version(version))
+				}
+				return del.Exec(ctx)
+			},
+			version: func(ctx context.Context, id int) (int, error) {
+				return c.Test2Schema.Query().Where(test2schema.ID(id)).Select(test2schema.FieldVersion).Int(ctx)
+			},
+		}, nil
+	case "test3schema":
+		return entityWriter{
+			create: func() ent.Mutation { return c.Test3Schema.Create().Mutation() },
+			update: func(id, version int) ent.Mutation {
+				update := c.Test3Schema.UpdateOneID(id).AddVersion(1)
+				if version != anyVersion {
+					update.Where(test3schema.Version(version))
+				}
+				return update.Mutation()
+			},
+			delete: func(ctx context.Context, id, version int) (int, error) {
+				del := c.Test3Schema.Delete().Where(test3schema.ID(id))
+				if version != anyVersion {
+					del.Where(test3schema.Version(version))
+				}
+				return del.Exec(ctx)
+			},
+			version: func(ctx context.Context, id int) (int, error) {
+				return c.Test3Schema.Query().Where(test3schema.ID(id)).Select(test3schema.FieldVersion).Int(ctx)
+			},
+		}, nil
+	default:
+		return entityWriter{}, fmt.Errorf("unsupported entity type: %s", entity)
+	}
+}
+
+// versionConflictError is returned when a write names a version of a record
+// that is no longer its current version: someone else changed it since the
+// client read it.
+type versionConflictError struct {
+	Version, Current int
+}
+
+func (e *versionConflictError) Error() string {
+	return fmt.Sprintf("version conflict: the record is at version %d, not %d", e.Current, e.Version)
+}
+
+// missedWrite explains why an update or delete of the record id at version
+// matched no row: the record is gone, or it is at another version.
+func missedWrite(ctx context.Context, writer entityWriter, id, version int) error {
+	current, err := writer.version(ctx, id)
+	if err != nil {
+		return err
+	}
+	return &versionConflictError{Version: version, Current: current}
+}
+
+// saveEntity creates a record of entity from values when create is set,
+// otherwise updates the given values of the record id, which must be at
+// version, and returns the record's id. Values are checked with
+// entityValues first.
+func saveEntity(ctx context.Context, c *ent.Client, entity string, fields map[string]schematool.SchemaFieldDefinition,
+	id, version int, create bool, values map[string]interface{}) (int, error) {
+	writer, err := newEntityWriter(c, entity)
+	if err != nil {
+		return 0, err
+	}
+	values, err = entityValues(fields, values, create)
+	if err != nil {
+		return 0, err
+	}
+	var m ent.Mutation
+	if create {
+		m = writer.create()
+	} else {
+		m = writer.update(id, version)
+	}
+	var errs filterast.ValidationErrors
+	for name, v := range values {
+		switch {
+		case v != nil:
+			err = m.SetField(name, v)
+		case !create:
+			err = m.ClearField(name)
+		default:
+			continue // an unset field of a new record is null already
+		}
+		if err != nil {
+			message := fmt.Sprintf("field '%s': %v", name, err)
+			if v == nil {
+				message = fmt.Sprintf("field '%s' cannot be null", name)
+			}
+			errs = append(errs, &filterast.ValidationError{Path: name, Code: filterast.CodeInvalidValue, Field: name, Value: v,
+				Message: message})
+		}
+	}
+	if len(errs) > 0 {
+		return 0, errs
+	}
+	if _, err := c.Mutate(ctx, m); err != nil {
+		if !create && ent.IsNotFound(err) {
+			return 0, missedWrite(ctx, writer, id, version)
+		}
+		return 0, err
+	}
+	if create {
+		id, _ = m.(interface{ ID() (int, bool) }).ID()
+	}
+	return id, nil
+}
+
+// deleteEntity deletes the record id of entity, which must be at version.
+func deleteEntity(ctx context.Context, c *ent.Client, entity string, id, version int) error {
+	writer, err := newEntityWriter(c, entity)
+	if err != nil {
+		return err
+	}
+	deleted, err := writer.delete(ctx, id, version)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return missedWrite(ctx, writer, id, version)
+	}
+	return nil
+}
+
+// entityValues checks a record's values, keyed by field name, against the
+// entity's fields and returns them keyed by the fields' declared names and
+// coerced to their Go types. Computed fields cannot be written, required
+// fields cannot be null or empty, and fields with allowed values only take
+// those. A new record must have a value for every required field. Problems
+// are reported as ValidationErrors addressed by the value's key.
+func entityValues(fields map[string]schematool.SchemaFieldDefinition, values map[string]interface{}, create bool) (map[string]interface{}, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var errs filterast.ValidationErrors
+	fail := func(name string, code string, value interface{}, format string, args ...interface{}) {
+		errs = append(errs, &filterast.ValidationError{Path: name, Code: code, Field: name, Value: value,
+			Message: fmt.Sprintf(format, args...)})
+	}
+	result := make(map[string]interface{}, len(values))
+	given := make(map[string]bool, len(values))
+	for _, name := range names {
+		v := values[name]
+		field, found := fields[strings.ToLower(name)]
+		if !found {
+			fail(name, filterast.CodeUnknownField, v, "field '%s' not found in schema", name)
+			continue
+		}
+		given[field.Name] = true
+		switch {
+		case field.Expression != "":
+			fail(name, filterast.CodeInvalidValue, v, "field '%s' is computed and cannot be written", name)
+			continue
+		case isEmptyValue(v) && field.Required:
+			fail(name, filterast.CodeInvalidValue, v, "field '%s' is required", name)
+			continue
+		case v == nil:
+			result[field.Name] = nil
+			continue
+		}
+		coerced, err := filterast.CoerceValue(field.Type, v)
+		if err != nil {
+			fail(name, filterast.CodeInvalidValue, v, "invalid value for field '%s': %v", name, err)
+			continue
+		}
+		if len(field.Values) > 0 && !containsValue(field.Values, coerced) {
+			fail(name, filterast.CodeInvalidValue, v, "field '%s' must be one of %v", name, field.Values)
+			continue
+		}
+		result[field.Name] = coerced
+	}
+	if create {
+		var missing []string
+		for _, field := range fields {
+			if field.Required && field.Expression == "" && !given[field.Name] {
+				missing = append(missing, field.Name)
+			}
+		}
+		sort.Strings(missing)
+		for _, name := range missing {
+			fail(name, filterast.CodeInvalidValue, nil, "field '%s' is required", name)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+// isEmptyValue reports whether v is null or an empty string, which a
+// required field does not accept.
+func isEmptyValue(v interface{}) bool {
+	s, isStr := v.(string)
+	return v == nil || (isStr && s == "")
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, allowed := range values {
+		if filterast.CompareValues(allowed, v) == 0 {
+			return true
+		}
+	}
+	return false
+}
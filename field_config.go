@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"transaction-filter-backend/dynamictablefilter"
+	"transaction-filter-backend/filterast"
+	"transaction-filter-backend/schematool"
+)
+
+// fieldConfig is an item of DevExtreme FilterBuilder's fields option that
+// also works as a DataGrid column: the grid reads validationRules and
+// allowEditing, which the filter builder ignores.
+type fieldConfig struct {
+	DataField        string           `json:"dataField"`
+	Caption          string           `json:"caption"`
+	DataType         string           `json:"dataType"`
+	Format           string           `json:"format,omitempty"`
+	FilterOperations []string         `json:"filterOperations"`
+	Lookup           *fieldLookup     `json:"lookup,omitempty"`
+	ValidationRules  []validationRule `json:"validationRules,omitempty"`
+	AllowEditing     bool             `json:"allowEditing"`
+}
+
+// fieldLookup lists the values a field is restricted to.
+type fieldLookup struct {
+	DataSource []interface{} `json:"dataSource"`
+}
+
+type validationRule struct {
+	Type string `json:"type"`
+}
+
+// customOperation describes an operator of this backend that DevExtreme
+// does not know, for FilterBuilder's customOperations option. The client
+// still provides its editor; a fuzzy value is a {"value", "distance"} object.
+type customOperation struct {
+	Name      string   `json:"name"`
+	Caption   string   `json:"caption"`
+	DataTypes []string `json:"dataTypes"`
+	HasValue  bool     `json:"hasValue"`
+}
+
+var customOperations = []customOperation{
+	{Name: "matches", Caption: "Matches", DataTypes: []string{"string"}, HasValue: true},
+	{Name: "like", Caption: "Is like", DataTypes: []string{"string"}, HasValue: true},
+	{Name: "fuzzy", Caption: "Is similar to", DataTypes: []string{"string"}, HasValue: true},
+}
+
+// devExtremeTypes maps schema field types to a DevExtreme dataType and the
+// format used when the field declares none.
+var devExtremeTypes = map[string]struct{ dataType, format string }{
+	"string":    {"string", ""},
+	"text":      {"string", ""},
+	"int":       {"number", "#,##0"},
+	"float64":   {"number", "#,##0.##"},
+	"bool":      {"boolean", ""},
+	"time.Time": {"datetime", "yyyy-MM-dd HH:mm"},
+}
+
+// fieldConfigHandler answers GET /fields?entity=<name> or ?table=<name> with
+// the DevExtreme configuration of the fields of an ent entity or dynamic
+// table, in declared order, and the custom operations their
+// filterOperations refer to. Only stored fields of ent entities are
+// editable.
+func fieldConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	entity, table := r.URL.Query().Get("entity"), r.URL.Query().Get("table")
+	var fields []schematool.SchemaFieldDefinition
+	switch {
+	case entity != "":
+		adapter, err := GetAdapter(entity)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("No adapter for entity '%s'", entity), http.StatusNotFound)
+			return
+		}
+		fields = orderedFields(adapter)
+	case table != "":
+		schema, err := dynamictablefilter.LoadTableSchema(table)
+		if err != nil {
+			http.Error(w, "Schema not found for table "+table, http.StatusNotFound)
+			return
+		}
+		fields = schema.Fields
+	default:
+		http.Error(w, "Missing 'entity' or 'table' query parameter", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Fields           []fieldConfig     `json:"fields"`
+		CustomOperations []customOperation `json:"customOperations"`
+	}{fieldConfigs(fields, entity != ""), customOperations})
+}
+
+// orderedFields returns an adapter's fields in declared order when it keeps
+// its schema definition, as GenericEntAdapter does, otherwise by name.
+func orderedFields(adapter EntityAdapter) []schematool.SchemaFieldDefinition {
+	if withSchema, ok := adapter.(interface {
+		Schema() *dynamictablefilter.TableSchema
+	}); ok {
+		return withSchema.Schema().Fields
+	}
+	fields := make([]schematool.SchemaFieldDefinition, 0, len(adapter.Fields()))
+	for _, field := range adapter.Fields() {
+		fields = append(fields, field)
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+// fieldConfigs converts schema fields to DevExtreme field configurations.
+// Stored fields are editable when editable is set; computed fields never are.
+func fieldConfigs(fields []schematool.SchemaFieldDefinition, editable bool) []fieldConfig {
+	configs := make([]fieldConfig, 0, len(fields))
+	for _, field := range fields {
+		types := devExtremeTypes[field.Type]
+		config := fieldConfig{
+			DataField:        field.Name,
+			Caption:          field.Caption,
+			DataType:         types.dataType,
+			Format:           field.Format,
+			FilterOperations: filterast.OperatorsForType(field.Type),
+			AllowEditing:     editable && field.Expression == "",
+		}
+		if config.Caption == "" {
+			config.Caption = filterast.FieldCaption(field.Name)
+		}
+		if config.Format == "" {
+			config.Format = types.format
+		}
+		if len(field.Values) > 0 {
+			config.Lookup = &fieldLookup{DataSource: field.Values}
+		}
+		if field.Required {
+			config.ValidationRules = append(config.ValidationRules, validationRule{Type: "required"})
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
@@ -0,0 +1,368 @@
+// Package fieldexpr compiles the expressions of computed (virtual) fields
+// declared in schema definitions, e.g. "retail_price - cost_price" or
+// "sku || ' ' || product_name".
+//
+// An expression combines stored fields, number literals and single-quoted
+// string literals with + - * / on numbers and || on strings, plus
+// parentheses and unary minus. It is type-checked once against the schema
+// and can then be rendered as SQL for ent entities or evaluated in Go for
+// dynamic tables, with the same semantics: a null operand or a division by
+// zero yields null, and "/" always divides as float64.
+package fieldexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"transaction-filter-backend/filterast"
+	"transaction-filter-backend/schematool"
+)
+
+// Expr is a compiled, type-checked expression.
+type Expr struct {
+	root node
+	// Type is the schema type of the result: "int", "float64" or "string".
+	Type string
+}
+
+type node interface {
+	typ() string
+}
+
+type fieldNode struct {
+	field schematool.SchemaFieldDefinition
+	t     string
+}
+
+type numberNode struct {
+	text  string
+	value float64
+	t     string
+}
+
+type stringNode struct {
+	value string
+}
+
+type negNode struct {
+	operand node
+}
+
+type binaryNode struct {
+	op          string // "+", "-", "*", "/" or "||"
+	left, right node
+	t           string
+}
+
+func (n *fieldNode) typ() string  { return n.t }
+func (n *numberNode) typ() string { return n.t }
+func (*stringNode) typ() string   { return "string" }
+func (n *negNode) typ() string    { return n.operand.typ() }
+func (n *binaryNode) typ() string { return n.t }
+
+// Parse compiles src against fields (keyed by lower-cased name). Only
+// int, float64, string and text fields can be used.
+func Parse(src string, fields map[string]schematool.SchemaFieldDefinition) (*Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, fields: fields}
+	root, err := p.concat()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+	}
+	return &Expr{root: root, Type: root.typ()}, nil
+}
+
+// Fields returns the names of the stored fields the expression reads.
+func (e *Expr) Fields() []string {
+	var names []string
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case *fieldNode:
+			names = append(names, n.field.Name)
+		case *negNode:
+			walk(n.operand)
+		case *binaryNode:
+			walk(n.left)
+			walk(n.right)
+		}
+	}
+	walk(e.root)
+	return names
+}
+
+// SQL renders the expression for SQLite, fully parenthesized, quoting each
+// field as a column named after the lower-cased field name.
+func (e *Expr) SQL() string {
+	var sb strings.Builder
+	writeSQL(&sb, e.root)
+	return sb.String()
+}
+
+func writeSQL(sb *strings.Builder, n node) {
+	switch n := n.(type) {
+	case *fieldNode:
+		sb.WriteString("`" + strings.ToLower(n.field.Name) + "`")
+	case *numberNode:
+		sb.WriteString(n.text)
+	case *stringNode:
+		sb.WriteString("'" + strings.ReplaceAll(n.value, "'", "''") + "'")
+	case *negNode:
+		sb.WriteString("(-")
+		writeSQL(sb, n.operand)
+		sb.WriteString(")")
+	case *binaryNode:
+		sb.WriteString("(")
+		if n.op == "/" {
+			// SQLite divides integers as integers; Eval always divides as
+			// float64, and so does a REAL operand here.
+			sb.WriteString("CAST(")
+			writeSQL(sb, n.left)
+			sb.WriteString(" AS REAL)")
+		} else {
+			writeSQL(sb, n.left)
+		}
+		sb.WriteString(" " + n.op + " ")
+		writeSQL(sb, n.right)
+		sb.WriteString(")")
+	}
+}
+
+// Eval computes the expression for a record holding raw JSON values keyed
+// by field name. The result is an int, float64 or string matching Type, or
+// nil when an operand is null or a divisor is zero.
+func (e *Expr) Eval(record map[string]interface{}) interface{} {
+	return eval(e.root, record)
+}
+
+func eval(n node, record map[string]interface{}) interface{} {
+	switch n := n.(type) {
+	case *fieldNode:
+		return fieldValue(n, record[n.field.Name])
+	case *numberNode:
+		if n.t == "int" {
+			return int(n.value)
+		}
+		return n.value
+	case *stringNode:
+		return n.value
+	case *negNode:
+		switch v := eval(n.operand, record).(type) {
+		case int:
+			return -v
+		case float64:
+			return -v
+		}
+		return nil
+	case *binaryNode:
+		left, right := eval(n.left, record), eval(n.right, record)
+		if left == nil || right == nil {
+			return nil
+		}
+		if n.op == "||" {
+			return left.(string) + right.(string)
+		}
+		// An int expression is computed as float64 when a fractional value
+		// was stored in one of its int fields, as SQLite does.
+		ai, aInt := left.(int)
+		bi, bInt := right.(int)
+		if n.t == "int" && aInt && bInt {
+			switch n.op {
+			case "+":
+				return ai + bi
+			case "-":
+				return ai - bi
+			default:
+				return ai * bi
+			}
+		}
+		a, b := toFloat(left), toFloat(right)
+		switch n.op {
+		case "+":
+			return a + b
+		case "-":
+			return a - b
+		case "*":
+			return a * b
+		default:
+			if b == 0 {
+				return nil
+			}
+			return a / b
+		}
+	}
+	return nil
+}
+
+// fieldValue converts a raw record value to the field's Go type the way the
+// dynamic table engine reads it (see filterast.RecordValue), so that Eval
+// agrees with SQLite; null and unconvertible values are treated as null.
+func fieldValue(n *fieldNode, raw interface{}) interface{} {
+	if raw == nil {
+		return nil
+	}
+	v, ok := filterast.RecordValue(n.t, raw)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func toFloat(v interface{}) float64 {
+	if i, ok := v.(int); ok {
+		return float64(i)
+	}
+	return v.(float64)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]schematool.SchemaFieldDefinition
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) concat() (node, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("||") {
+		p.next()
+		right, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		if left.typ() != "string" || right.typ() != "string" {
+			return nil, fmt.Errorf("operator || needs string operands, got %s and %s", left.typ(), right.typ())
+		}
+		left = &binaryNode{op: "||", left: left, right: right, t: "string"}
+	}
+	return left, nil
+}
+
+func (p *parser) additive() (node, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("+") || p.peek().is("-") {
+		op := p.next().text
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) term() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("*") || p.peek().is("/") {
+		op := p.next().text
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = arithmetic(op, left, right); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func arithmetic(op string, left, right node) (node, error) {
+	if !isNumeric(left.typ()) || !isNumeric(right.typ()) {
+		return nil, fmt.Errorf("operator %s needs numeric operands, got %s and %s", op, left.typ(), right.typ())
+	}
+	t := "float64"
+	if op != "/" && left.typ() == "int" && right.typ() == "int" {
+		t = "int"
+	}
+	return &binaryNode{op: op, left: left, right: right, t: t}, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek().is("-") {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if !isNumeric(operand.typ()) {
+			return nil, fmt.Errorf("unary - needs a numeric operand, got %s", operand.typ())
+		}
+		return &negNode{operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", tok.text, tok.pos)
+		}
+		t := "int"
+		if strings.ContainsAny(tok.text, ".eE") {
+			t = "float64"
+		}
+		return &numberNode{text: tok.text, value: value, t: t}, nil
+	case tokString:
+		return &stringNode{value: tok.text}, nil
+	case tokIdent:
+		field, ok := p.fields[strings.ToLower(tok.text)]
+		if !ok {
+			return nil, fmt.Errorf("unknown field '%s' at offset %d", tok.text, tok.pos)
+		}
+		switch field.Type {
+		case "int", "float64":
+			return &fieldNode{field: field, t: field.Type}, nil
+		case "string", "text":
+			return &fieldNode{field: field, t: "string"}, nil
+		}
+		return nil, fmt.Errorf("field '%s' of type %s cannot be used in an expression", field.Name, field.Type)
+	case tokPunct:
+		if tok.text == "(" {
+			inner, err := p.concat()
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.next(); !closing.is(")") {
+				return nil, fmt.Errorf("expected ) at offset %d, got %s", closing.pos, closing)
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s at offset %d", tok, tok.pos)
+}
+
+func isNumeric(t string) bool {
+	return t == "int" || t == "float64"
+}
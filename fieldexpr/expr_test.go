@@ -0,0 +1,104 @@
+package fieldexpr
+
+import (
+	"testing"
+
+	"transaction-filter-backend/schematool"
+)
+
+var testFields = map[string]schematool.SchemaFieldDefinition{
+	"qty":   {Name: "qty", Type: "int"},
+	"price": {Name: "price", Type: "float64"},
+	"cost":  {Name: "cost", Type: "float64"},
+	"sku":   {Name: "sku", Type: "string"},
+	"name":  {Name: "name", Type: "text"},
+	"due":   {Name: "due", Type: "time.Time"},
+}
+
+func TestParseAndEval(t *testing.T) {
+	record := map[string]interface{}{"qty": 4.0, "price": 12.5, "cost": 10.0, "sku": "A-1", "name": "Lamp"}
+	testCases := []struct {
+		src          string
+		expectedType string
+		expectedSQL  string
+		expected     interface{}
+	}{
+		{"price - cost", "float64", "(`price` - `cost`)", 2.5},
+		{"qty * 2 + 1", "int", "((`qty` * 2) + 1)", 9},
+		{"qty + 2 * 3", "int", "(`qty` + (2 * 3))", 10},
+		{"(qty + 2) * 3", "int", "((`qty` + 2) * 3)", 18},
+		{"qty / 8", "float64", "(CAST(`qty` AS REAL) / 8)", 0.5},
+		{"-qty + PRICE", "float64", "((-`qty`) + `price`)", 8.5},
+		{"price * 1.5e1", "float64", "(`price` * 1.5e1)", 187.5},
+		{"sku || ' ' || name", "string", "((`sku` || ' ') || `name`)", "A-1 Lamp"},
+		{"'it''s ' || sku", "string", "('it''s ' || `sku`)", "it's A-1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.src, func(t *testing.T) {
+			expr, err := Parse(tc.src, testFields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if expr.Type != tc.expectedType {
+				t.Errorf("expected type %s, got %s", tc.expectedType, expr.Type)
+			}
+			if sql := expr.SQL(); sql != tc.expectedSQL {
+				t.Errorf("expected SQL %s, got %s", tc.expectedSQL, sql)
+			}
+			if got := expr.Eval(record); got != tc.expected {
+				t.Errorf("expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestEvalNulls(t *testing.T) {
+	for _, src := range []string{"price - cost", "qty / (qty - 4)", "sku || name", "-cost"} {
+		expr, err := Parse(src, testFields)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", src, err)
+		}
+		if got := expr.Eval(map[string]interface{}{"qty": 4.0, "price": 1.0, "sku": "x"}); got != nil {
+			t.Errorf("expected %s to be null, got %#v", src, got)
+		}
+	}
+}
+
+func TestEvalCoercesRecordValues(t *testing.T) {
+	// A fractional int is not truncated and a number in a text field reads
+	// as text, as in the dynamic table engine and SQLite.
+	record := map[string]interface{}{"qty": 2.5, "price": 2.0, "sku": 42.0, "name": "x"}
+	for src, expected := range map[string]interface{}{
+		"qty + 1":     3.5,
+		"qty * price": 5.0,
+		"sku || name": "42x",
+	} {
+		expr, err := Parse(src, testFields)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", src, err)
+		}
+		if got := expr.Eval(record); got != expected {
+			t.Errorf("expected %s to be %#v, got %#v", src, expected, got)
+		}
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"price -",
+		"(price - cost",
+		"price cost",
+		"missing + 1",
+		"due + 1",
+		"sku + 1",
+		"qty || sku",
+		"-sku",
+		"'unterminated",
+		"price % 2",
+	} {
+		if _, err := Parse(src, testFields); err == nil {
+			t.Errorf("expected an error for %q", src)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package filterast parses DevExtreme filter arrays into a typed tree that
+// both the ent (SQL) and the dynamic-table (in-memory) engines consume.
+//
+// Parsing resolves every field against a schema, checks that the operator is
+// allowed for the field's type and coerces the value once, so backends only
+// have to translate already-valid nodes.
+package filterast
+
+import (
+	"fmt"
+	"strings"
+
+	"transaction-filter-backend/schematool"
+)
+
+// Node is one element of a parsed filter tree: *Group, *Not or *Condition.
+type Node interface {
+	isNode()
+}
+
+// GroupOp is the logical operator joining the children of a Group.
+type GroupOp string
+
+const (
+	And GroupOp = "and"
+	Or  GroupOp = "or"
+)
+
+// Group joins two or more children with a single logical operator. Mixed
+// DevExtreme groups are split by precedence during parsing, so an OR group
+// never directly contains an AND-ed run without wrapping it in its own Group.
+type Group struct {
+	Op       GroupOp
+	Children []Node
+}
+
+// Not negates its child, from DevExtreme's ["!", condition] form.
+type Not struct {
+	Child Node
+}
+
+// Condition is a single field comparison with a resolved field definition
+// and a value already converted to the field's Go type.
+type Condition struct {
+	Field schematool.SchemaFieldDefinition
+	// Part is the date part compared instead of the whole time.Time value,
+	// e.g. PartMonth for "date.Month"; empty for plain fields.
+	Part     string
+	Operator string // lower-cased DevExtreme operator
+	// Value holds the coerced operand of scalar operators; nil means a null
+	// comparison for "=" and "<>" unless ValueField is set. Unused by list, range and blank operators.
+	Value interface{}
+	// ValueField is set instead of Value when the condition compares the
+	// field with another field of the same record.
+	ValueField *FieldRef
+	// Values holds the coerced operands of "anyof"/"noneof", and the lower and
+	// upper bound of "between".
+	Values []interface{}
+	// Collation is how a string field's text is compared, one of the
+	// Collation constants; empty for other types.
+	Collation string
+	// Raw is the operand as written in the filter. ToFilterArray uses it to
+	// keep relative date expressions such as "now-7d" unresolved.
+	Raw interface{}
+}
+
+func (*Group) isNode()     {}
+func (*Not) isNode()       {}
+func (*Condition) isNode() {}
+
+// Parse converts a DevExtreme filter array into a Node, resolving field names
+// case-insensitively in fields (keyed by lower-cased name). A nil or empty
+// filter yields a nil Node, meaning "match everything". When the filter has
+// problems the error is a ValidationErrors listing all of them.
+func Parse(filter interface{}, fields map[string]schematool.SchemaFieldDefinition) (Node, error) {
+	if filter == nil {
+		return nil, nil
+	}
+	p := &parser{fields: fields}
+	node := p.parseOperand(filter, "")
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+	return node, nil
+}
+
+// parser carries the schema and the errors collected so far; parsing goes on
+// after an error so that sibling conditions are validated too.
+type parser struct {
+	fields map[string]schematool.SchemaFieldDefinition
+	errs   ValidationErrors
+}
+
+func (p *parser) fail(path, code, format string, args ...interface{}) {
+	p.errs = append(p.errs, &ValidationError{Path: path, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+func (p *parser) parseOperand(item interface{}, path string) Node {
+	operand, ok := item.([]interface{})
+	if !ok {
+		p.fail(path, CodeInvalidFilter, "filter operand must be an array, got %T: '%v'", item, item)
+		return nil
+	}
+	return p.parseArray(operand, path)
+}
+
+func (p *parser) parseArray(filterArray []interface{}, path string) Node {
+	if len(filterArray) == 0 {
+		return nil
+	}
+
+	// Handle unary NOT: ["!", [condition]]
+	if s, ok := filterArray[0].(string); ok && s == "!" {
+		if len(filterArray) != 2 {
+			p.fail(path, CodeMalformedNot, "malformed NOT filter: expected 2 elements, got %d", len(filterArray))
+			return nil
+		}
+		child := p.parseOperand(filterArray[1], indexPath(path, 1))
+		if child == nil {
+			return nil
+		}
+		return &Not{Child: child}
+	}
+
+	// Handle simple condition: ["field", "operator", value] or the two-element
+	// equality shorthand ["field", value]
+	if fieldName, ok := filterArray[0].(string); ok && (len(filterArray) == 2 || len(filterArray) == 3) && !IsLogicalKeyword(fieldName) {
+		operator, value, valueIndex := "=", filterArray[1], 1
+		if len(filterArray) == 3 {
+			opStr, okOp := filterArray[1].(string)
+			if !okOp {
+				p.errs = append(p.errs, &ValidationError{
+					Path: indexPath(path, 1), Code: CodeInvalidOperator, Field: fieldName,
+					Message: fmt.Sprintf("operator in simple condition must be a string, got %T", filterArray[1]),
+				})
+				return nil
+			}
+			operator, value, valueIndex = opStr, filterArray[2], 2
+		}
+		cond, errs := newCondition(p.fields, fieldName, operator, value, path, valueIndex)
+		p.errs = append(p.errs, errs...)
+		return cond
+	}
+
+	// Handle group condition: [condition1, "and"|"or", condition2, ...]
+	operands, ops, ok := p.splitGroup(filterArray, path)
+	children := make([]Node, 0, len(operands))
+	for _, operand := range operands {
+		children = append(children, p.parseOperand(filterArray[operand], indexPath(path, operand)))
+	}
+	if !ok {
+		return nil
+	}
+
+	// AND binds tighter than OR, matching DevExtreme's client-side evaluation:
+	// [C1, "or", C2, "and", C3] -> C1 OR (C2 AND C3). The flat list is split
+	// into runs of AND-ed conditions at every "or", and the runs are OR-ed.
+	var orTerms []Node
+	andRun := []Node{children[0]}
+	for i, op := range ops {
+		if op == Or {
+			orTerms = append(orTerms, newGroup(And, andRun))
+			andRun = nil
+		}
+		andRun = append(andRun, children[i+1])
+	}
+	orTerms = append(orTerms, newGroup(And, andRun))
+	return newGroup(Or, orTerms)
+}
+
+// newGroup lifts children that are groups with the same operator and
+// collapses groups of zero or one child. A nil (empty) child matches every
+// record: an AND group drops it, and an OR group holding one matches every
+// record too, so it collapses to nil.
+func newGroup(op GroupOp, children []Node) Node {
+	flat := make([]Node, 0, len(children))
+	for _, child := range children {
+		if child == nil {
+			if op == Or {
+				return nil
+			}
+			continue
+		}
+		if g, ok := child.(*Group); ok && g.Op == op {
+			flat = append(flat, g.Children...)
+			continue
+		}
+		flat = append(flat, child)
+	}
+	switch len(flat) {
+	case 0:
+		return nil
+	case 1:
+		return flat[0]
+	}
+	return &Group{Op: op, Children: flat}
+}
+
+// IsLogicalKeyword reports whether s is a group or negation keyword rather
+// than a field name.
+func IsLogicalKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "and", "or", "!":
+		return true
+	}
+	return false
+}
+
+// splitGroup returns the indexes of a group's operands and the logical
+// operators between them, so that ops[i] joins operands[i] and operands[i+1].
+// Operands written next to each other without an operator are joined by an
+// implicit "and", as DevExtreme does for [[c1], [c2]]. ok is false when the
+// group's structure is invalid; the problems are recorded on the parser.
+func (p *parser) splitGroup(group []interface{}, path string) (operands []int, ops []GroupOp, ok bool) {
+	ok = true
+	expectOperand := true
+	for i, item := range group {
+		if opStr, isStr := item.(string); isStr {
+			op := GroupOp(strings.ToLower(opStr))
+			if op != And && op != Or {
+				p.fail(indexPath(path, i), CodeInvalidLogicalOperator, "invalid logical operator in group: '%s'", opStr)
+				ok = false
+			} else if expectOperand {
+				p.fail(indexPath(path, i), CodeMalformedGroup, "malformed group filter: operator '%s' must follow a condition", opStr)
+				ok = false
+			}
+			ops = append(ops, op)
+			expectOperand = true
+			continue
+		}
+		if !expectOperand {
+			ops = append(ops, And)
+		}
+		operands = append(operands, i)
+		expectOperand = false
+	}
+	if expectOperand && ok {
+		p.fail(indexPath(path, len(group)-1), CodeMalformedGroup, "malformed group filter: missing condition after operator")
+		ok = false
+	}
+	return operands, ops, ok
+}
@@ -0,0 +1,324 @@
+package filterast
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"transaction-filter-backend/schematool"
+)
+
+var testFields = map[string]schematool.SchemaFieldDefinition{
+	"name":   {Name: "name", Type: "string"},
+	"qty":    {Name: "qty", Type: "int"},
+	"price":  {Name: "price", Type: "float64"},
+	"active": {Name: "active", Type: "bool"},
+	"due":    {Name: "due", Type: "time.Time"},
+}
+
+func TestParseBuildsPrecedenceTree(t *testing.T) {
+	node, err := Parse([]interface{}{
+		[]interface{}{"qty", "=", 1},
+		"or",
+		[]interface{}{"qty", "=", 2},
+		"and",
+		[]interface{}{"active", true},
+		[]interface{}{"!", []interface{}{"name", "contains", "x"}},
+	}, testFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	or, ok := node.(*Group)
+	if !ok || or.Op != Or || len(or.Children) != 2 {
+		t.Fatalf("expected OR group with 2 children, got %#v", node)
+	}
+	and, ok := or.Children[1].(*Group)
+	if !ok || and.Op != And || len(and.Children) != 3 {
+		t.Fatalf("expected AND group with 3 children, got %#v", or.Children[1])
+	}
+	if _, ok := and.Children[2].(*Not); !ok {
+		t.Fatalf("expected NOT node, got %#v", and.Children[2])
+	}
+}
+
+func TestParseFlattensAndDropsEmptyGroups(t *testing.T) {
+	node, err := Parse([]interface{}{
+		[]interface{}{[]interface{}{"qty", ">", 1}, "and", []interface{}{"qty", "<", 5}},
+		"and",
+		[]interface{}{},
+		"and",
+		[]interface{}{[]interface{}{"name", "=", "a"}},
+	}, testFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g, ok := node.(*Group)
+	if !ok || g.Op != And || len(g.Children) != 3 {
+		t.Fatalf("expected flat AND group with 3 children, got %#v", node)
+	}
+}
+
+func TestParseCoercesValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filter   []interface{}
+		expected interface{}
+	}{
+		{name: "int from float64", filter: []interface{}{"qty", "=", 3.0}, expected: 3},
+		{name: "int from string", filter: []interface{}{"QTY", "=", "3"}, expected: 3},
+		{name: "float from int", filter: []interface{}{"price", ">", 2}, expected: 2.0},
+		{name: "bool from string", filter: []interface{}{"active", "=", "TRUE"}, expected: true},
+		{name: "time from date string", filter: []interface{}{"due", "<", "2024-01-05"}, expected: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{name: "date part as int", filter: []interface{}{"due.month", "=", "12"}, expected: 12},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			node, err := Parse(tc.filter, testFields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cond, ok := node.(*Condition)
+			if !ok {
+				t.Fatalf("expected a condition, got %#v", node)
+			}
+			if cond.Value != tc.expected {
+				t.Errorf("expected value %#v, got %#v", tc.expected, cond.Value)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidConditions(t *testing.T) {
+	testCases := []struct {
+		name   string
+		filter interface{}
+	}{
+		{name: "not an array", filter: "qty = 1"},
+		{name: "unknown field", filter: []interface{}{"missing", "=", 1}},
+		{name: "operator not allowed for type", filter: []interface{}{"active", ">", true}},
+		{name: "unconvertible value", filter: []interface{}{"qty", "=", "many"}},
+		{name: "fractional int", filter: []interface{}{"qty", "=", 1.5}},
+		{name: "between needs two values", filter: []interface{}{"qty", "between", []interface{}{1}}},
+		{name: "anyof needs an array", filter: []interface{}{"name", "anyof", "a"}},
+		{name: "invalid regular expression", filter: []interface{}{"name", "matches", "a)"}},
+		{name: "like on a number", filter: []interface{}{"qty", "like", "1%"}},
+		{name: "fuzzy needs an object", filter: []interface{}{"name", "fuzzy", "a"}},
+		{name: "fuzzy with a negative distance", filter: []interface{}{"name", "fuzzy", map[string]interface{}{"value": "a", "distance": -1.0}}},
+		{name: "ordering against null", filter: []interface{}{"qty", "<", nil}},
+		{name: "date part on non-time field", filter: []interface{}{"qty.Year", "=", 2024}},
+		{name: "unknown date part", filter: []interface{}{"due.Fortnight", "=", 1}},
+		{name: "non-array group operand", filter: []interface{}{[]interface{}{"qty", "=", 1}, "and", "x"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Parse(tc.filter, testFields); err == nil {
+				t.Fatalf("expected an error for %#v", tc.filter)
+			}
+		})
+	}
+}
+
+func TestParseReportsEveryErrorWithPath(t *testing.T) {
+	_, err := Parse([]interface{}{
+		[]interface{}{"missing", "=", 1},
+		"and",
+		[]interface{}{"qty", "between", []interface{}{1, "x"}},
+		"xor",
+		[]interface{}{"!", []interface{}{"active", ">", true}},
+		"or",
+		[]interface{}{"due", "=", "someday"},
+	}, testFields)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("expected ValidationErrors, got %T: %v", err, err)
+	}
+	expected := []struct{ path, code string }{
+		{"[3]", CodeInvalidLogicalOperator},
+		{"[0][0]", CodeUnknownField},
+		{"[2][2][1]", CodeInvalidValue},
+		{"[4][1][1]", CodeUnsupportedOperator},
+		{"[6][2]", CodeInvalidValue},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, e := range expected {
+		if errs[i].Path != e.path || errs[i].Code != e.code {
+			t.Errorf("error %d: expected %s %s, got %s %s (%s)", i, e.path, e.code, errs[i].Path, errs[i].Code, errs[i].Message)
+		}
+	}
+	if errs[4].Field != "due" || errs[4].Operator != "=" || errs[4].Value != "someday" {
+		t.Errorf("expected offending field/operator/value on error, got %+v", errs[4])
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	sorts, err := ParseSort([]interface{}{
+		"QTY",
+		map[string]interface{}{"selector": "due.Month", "desc": true},
+	}, testFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sorts) != 2 || sorts[0].Field.Name != "qty" || sorts[0].Desc ||
+		sorts[1].Field.Name != "due" || sorts[1].Part != PartMonth || !sorts[1].Desc {
+		t.Errorf("unexpected sort criteria %+v", sorts)
+	}
+
+	_, err = ParseSort([]interface{}{"qty", "missing", map[string]interface{}{"desc": true}}, testFields)
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 2 || errs[0].Path != "[1]" || errs[0].Code != CodeUnknownField || errs[1].Path != "[2]" {
+		t.Errorf("expected errors at [1] and [2], got %v", err)
+	}
+}
+
+func TestParseGroup(t *testing.T) {
+	groups, err := ParseGroup([]interface{}{
+		"name",
+		map[string]interface{}{"selector": "due", "groupInterval": "Month", "desc": true, "isExpanded": false},
+		map[string]interface{}{"selector": "price", "groupInterval": 2.5},
+	}, testFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 3 || groups[0].Collation != DefaultCollation || !groups[0].IsExpanded ||
+		groups[1].Part != PartMonth || !groups[1].Desc || groups[1].IsExpanded || groups[1].Type() != "int" ||
+		groups[2].Interval != 2.5 || groups[2].Type() != "float64" {
+		t.Errorf("unexpected group levels %+v", groups)
+	}
+
+	_, err = ParseGroup([]interface{}{
+		map[string]interface{}{"selector": "name", "groupInterval": 10.0},
+		map[string]interface{}{"selector": "qty", "groupInterval": "year"},
+		map[string]interface{}{"selector": "qty", "groupInterval": 0.0},
+		"active",
+	}, testFields)
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 3 || errs[0].Path != "[0]" || errs[1].Path != "[1]" || errs[2].Path != "[2]" {
+		t.Errorf("expected errors at [0], [1] and [2], got %v", err)
+	}
+}
+
+func TestNestGroups(t *testing.T) {
+	groups := []GroupField{{IsExpanded: true}, {IsExpanded: true}}
+	counts := []GroupCount{
+		{Keys: []interface{}{nil, 1}, Count: 1},
+		{Keys: []interface{}{2024, 1}, Count: 2},
+		{Keys: []interface{}{2024, 3}, Count: 1},
+	}
+	items := NestGroups(counts, groups)
+	if len(items) != 2 || items[0].Key != nil || items[0].Count != 1 || items[1].Count != 3 {
+		t.Fatalf("unexpected top-level groups %+v", items)
+	}
+	records := []map[string]interface{}{{"n": 1}, {"n": 2}, {"n": 3}, {"n": 4}}
+	FillGroups(items, records)
+	months := items[1].Items.([]*GroupItem)
+	if len(months) != 2 || len(months[0].Items.([]map[string]interface{})) != 2 || months[1].Items.([]map[string]interface{})[0]["n"] != 4 {
+		t.Errorf("records handed out to the wrong groups: %+v", months)
+	}
+
+	groups[1].IsExpanded = false
+	if items := NestGroups(counts, groups); items[1].Items.([]*GroupItem)[0].Items != nil || GroupsExpanded(groups) {
+		t.Error("expected collapsed last-level groups without items")
+	}
+}
+
+func TestParseSummary(t *testing.T) {
+	summaries, err := ParseSummary([]interface{}{
+		map[string]interface{}{"selector": "price", "summaryType": "SUM"},
+		map[string]interface{}{"selector": "due.Year", "summaryType": "avg"},
+		map[string]interface{}{"selector": "due", "summaryType": "max"},
+		map[string]interface{}{"summaryType": "count"},
+	}, testFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(summaries) != 4 || summaries[0].Type != SummarySum || summaries[1].ValueType() != "int" || summaries[3].Field.Name != "" {
+		t.Errorf("unexpected summaries %+v", summaries)
+	}
+
+	_, err = ParseSummary([]interface{}{
+		map[string]interface{}{"selector": "name", "summaryType": "sum"},
+		map[string]interface{}{"selector": "active", "summaryType": "min"},
+		map[string]interface{}{"selector": "qty", "summaryType": "median"},
+		map[string]interface{}{"summaryType": "avg"},
+		map[string]interface{}{"selector": "name", "summaryType": "count"},
+	}, testFields)
+	errs, ok := err.(ValidationErrors)
+	if !ok || len(errs) != 4 || errs[3].Path != "[3]" {
+		t.Errorf("expected errors at [0] to [3], got %v", err)
+	}
+}
+
+func TestAggregateMerge(t *testing.T) {
+	sum, avg, min := Aggregate{Type: SummarySum}, Aggregate{Type: SummaryAvg}, Aggregate{Type: SummaryMin}
+	for _, v := range []interface{}{2, nil, 3.5} {
+		sum.Add(v)
+		avg.Add(v)
+		min.Add(v)
+	}
+	empty := Aggregate{Type: SummaryAvg}
+	empty.Merge(Aggregate{Type: SummaryAvg})
+	if sum.Value() != 5.5 || avg.Value() != 2.75 || min.Value() != 2 || empty.Value() != nil {
+		t.Errorf("unexpected results %v, %v, %v, %v", sum.Value(), avg.Value(), min.Value(), empty.Value())
+	}
+	count := Aggregate{Type: SummaryCount}
+	count.Add(nil)
+	if count.Value() != 1 || (Aggregate{Type: SummarySum}).Value() != 0 {
+		t.Errorf("expected count to include nulls and an empty sum to be 0")
+	}
+}
+
+func TestFoldCollations(t *testing.T) {
+	testCases := []struct {
+		a, b      string
+		collation string
+		equal     bool
+	}{
+		{"café", "café", CollationExact, true},
+		{"café", "CAFE", CollationExact, false},
+		{"Straße", "STRASSE", CollationCaseInsensitive, true},
+		{"café", "CAFÉ", CollationCaseInsensitive, true},
+		{"café", "CAFÉ", CollationCaseInsensitive, true}, // decomposed é
+		{"café", "CAFE", CollationCaseInsensitive, false},
+		{"café", "CAFE", CollationAccentInsensitive, true},
+		{"Zoë Ångström", "zoe angstrom", CollationAccentInsensitive, true},
+	}
+	for _, tc := range testCases {
+		if got := Fold(tc.a, tc.collation) == Fold(tc.b, tc.collation); got != tc.equal {
+			t.Errorf("%s: expected %q and %q equal=%v", tc.collation, tc.a, tc.b, tc.equal)
+		}
+	}
+}
+
+func TestSetCollation(t *testing.T) {
+	node, err := Parse([]interface{}{[]interface{}{"name", "=", "a"}, "or", []interface{}{"qty", "=", 1}}, testFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	name, qty := node.(*Group).Children[0].(*Condition), node.(*Group).Children[1].(*Condition)
+	if name.Collation != DefaultCollation || qty.Collation != "" {
+		t.Fatalf("expected default collation on strings only, got %q and %q", name.Collation, qty.Collation)
+	}
+	if err := SetCollation(node, CollationExact); err != nil || name.Collation != CollationExact || qty.Collation != "" {
+		t.Errorf("expected exact collation on strings only, got %q and %q (%v)", name.Collation, qty.Collation, err)
+	}
+	if err := SetCollation(node, "binary"); err == nil {
+		t.Error("expected an error for an unknown collation")
+	}
+}
+
+func TestCompilePatternCacheIsBounded(t *testing.T) {
+	for i := 0; i < 2*patternCacheSize; i++ {
+		if _, err := CompilePattern(fmt.Sprintf("^SKU-%d$", i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	re, err := CompilePattern(fmt.Sprintf("^SKU-%d$", 2*patternCacheSize-1))
+	if err != nil || !re.MatchString(fmt.Sprintf("SKU-%d", 2*patternCacheSize-1)) {
+		t.Fatalf("expected the cached pattern to match, got %v", err)
+	}
+	if n := len(patternCache.entries); n != patternCacheSize || patternCache.order.Len() != n {
+		t.Errorf("expected %d cached patterns, got %d", patternCacheSize, n)
+	}
+}
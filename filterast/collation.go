@@ -0,0 +1,126 @@
+package filterast
+
+import (
+	"fmt"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Collations select how conditions on string fields compare text. A schema
+// field may declare one in "collation", a request may override it for every
+// string field, and DefaultCollation applies otherwise.
+const (
+	// CollationExact compares text as stored, code point by code point.
+	CollationExact = "exact"
+	// CollationCaseInsensitive compares Unicode-normalized (NFC) text with
+	// full Unicode case folding, so "STRASSE" equals "straße".
+	CollationCaseInsensitive = "case-insensitive"
+	// CollationAccentInsensitive also drops combining marks after canonical
+	// decomposition, so "CAFE" equals "café". It implies case-insensitive.
+	CollationAccentInsensitive = "accent-insensitive"
+)
+
+// DefaultCollation is used for string fields without a collation. Exact
+// comparisons keep the plain SQL column, so SQLite can still use its
+// indexes; the other collations must be asked for.
+const DefaultCollation = CollationExact
+
+// ValidCollation reports whether collation is one of the Collation constants.
+func ValidCollation(collation string) bool {
+	switch collation {
+	case CollationExact, CollationCaseInsensitive, CollationAccentInsensitive:
+		return true
+	}
+	return false
+}
+
+// Fold returns the key under which s is compared for collation: two strings
+// are equal under a collation when their keys are equal, and substring and
+// pattern operators look at the keys too. Both engines use it, the dynamic
+// engine directly and SQLite through the fold function.
+func Fold(s, collation string) string {
+	switch collation {
+	case CollationExact:
+		return s
+	case CollationAccentInsensitive:
+		decomposed := []rune(norm.NFD.String(s))
+		kept := decomposed[:0]
+		for _, r := range decomposed {
+			if !unicode.Is(unicode.Mn, r) {
+				kept = append(kept, r)
+			}
+		}
+		return norm.NFC.String(cases.Fold().String(string(kept)))
+	default:
+		return norm.NFC.String(cases.Fold().String(norm.NFC.String(s)))
+	}
+}
+
+// SetCollation makes every condition on a string field in the tree use
+// collation, for a request-level override of the schema's collations. An
+// empty collation leaves the tree unchanged.
+func SetCollation(node Node, collation string) error {
+	if collation == "" {
+		return nil
+	}
+	if !ValidCollation(collation) {
+		return fmt.Errorf("unknown collation '%s', expected %s, %s or %s", collation, CollationExact, CollationCaseInsensitive, CollationAccentInsensitive)
+	}
+	switch n := node.(type) {
+	case *Condition:
+		if n.Collation != "" {
+			n.Collation = collation
+		}
+	case *Not:
+		return SetCollation(n.Child, collation)
+	case *Group:
+		for _, child := range n.Children {
+			if err := SetCollation(child, collation); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetSortCollation makes every sort criterion on a string field use
+// collation, the counterpart of SetCollation for a request's sort option.
+// An empty collation leaves the criteria unchanged.
+func SetSortCollation(sorts []SortField, collation string) {
+	if collation == "" {
+		return
+	}
+	for i := range sorts {
+		if sorts[i].Collation != "" {
+			sorts[i].Collation = collation
+		}
+	}
+}
+
+// SetGroupCollation makes every group level keyed by a string field use
+// collation, the counterpart of SetCollation for a request's group option.
+// An empty collation leaves the levels unchanged.
+func SetGroupCollation(groups []GroupField, collation string) {
+	if collation == "" {
+		return
+	}
+	for i := range groups {
+		if groups[i].Collation != "" {
+			groups[i].Collation = collation
+		}
+	}
+}
+
+// fieldCollation returns the collation conditions on a string field use by
+// default.
+func fieldCollation(fieldType, declared string) string {
+	if !IsStringType(fieldType) {
+		return ""
+	}
+	if declared == "" {
+		return DefaultCollation
+	}
+	return declared
+}
@@ -0,0 +1,117 @@
+package filterast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Helper to convert to int (from float64 which JSON unmarshals numbers to, or string)
+func convertToInt(val interface{}) (int, error) {
+	switch v := val.(type) {
+	case float64:
+		// Check if float64 has a fractional part
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("cannot convert float %f to int as it has a fractional part", v)
+		}
+		return int(v), nil
+	case float32:
+		if v != float32(int(v)) {
+			return 0, fmt.Errorf("cannot convert float32 %f to int as it has a fractional part", v)
+		}
+		return int(v), nil
+	case int:
+		return v, nil
+	case int32:
+		return int(v), nil
+	case int64:
+		return int(v), nil // Potential precision loss if int is 32-bit and int64 is large
+	case string:
+		var i int
+		_, err := fmt.Sscan(v, &i)
+		if err != nil {
+			// Try parsing as float first in case it's "10.0"
+			var f float64
+			_, ferr := fmt.Sscan(v, &f)
+			if ferr == nil {
+				if f != float64(int(f)) {
+					return 0, fmt.Errorf("cannot convert string float %s to int as it has a fractional part", v)
+				}
+				return int(f), nil
+			}
+		}
+		return i, err
+	default:
+		return 0, fmt.Errorf("cannot convert %T to int", val)
+	}
+}
+
+// Helper to convert to bool (from bool or the strings "true"/"false")
+func convertToBool(val interface{}) (bool, error) {
+	switch v := val.(type) {
+	case bool:
+		return v, nil
+	case string:
+		parsed, err := strconv.ParseBool(strings.ToLower(v))
+		if err != nil {
+			return false, fmt.Errorf("expected bool or 'true'/'false', got '%s'", v)
+		}
+		return parsed, nil
+	default:
+		return false, fmt.Errorf("value must be a boolean or string 'true'/'false', got %T", val)
+	}
+}
+
+// Helper to convert to time.Time (from string)
+// Recognizes RFC3339 and common date/datetime formats.
+func convertToTime(val interface{}) (time.Time, error) {
+	strVal, ok := val.(string)
+	if !ok {
+		// Check if it's already a time.Time (e.g. from database default)
+		if tVal, tOk := val.(time.Time); tOk {
+			return tVal, nil
+		}
+		return time.Time{}, fmt.Errorf("time value must be a string or time.Time, got %T", val)
+	}
+
+	layouts := []string{
+		time.RFC3339,
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05Z07:00", // RFC3339 with timezone
+		"2006-01-02T15:04:05",       // ISO8601 without timezone
+		"2006-01-02",                // Date only
+	}
+	for _, layout := range layouts {
+		t, err := time.Parse(layout, strVal)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse date string '%s' with known layouts", strVal)
+}
+
+// Helper to convert to float64 (from any numeric type or string)
+func convertToFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string: // Attempt to parse string to float
+		var f float64
+		_, err := fmt.Sscan(v, &f)
+		if err == nil {
+			return f, nil
+		}
+		return 0, fmt.Errorf("cannot convert string '%s' to float64: %w", v, err)
+	default:
+		return 0, fmt.Errorf("expected numeric type or string representation of number, got %T for value %+v", val, val)
+	}
+}
@@ -0,0 +1,95 @@
+package filterast
+
+import (
+	"strings"
+	"time"
+
+	"transaction-filter-backend/schematool"
+)
+
+// Date parts that can be appended to a time.Time field name in a filter, as
+// in ["date.DayOfWeek", "anyof", [0, 6]]. The extracted part is an int, so
+// the int operators apply. Months and days count from 1, DayOfWeek runs
+// from 0 (Sunday) to 6 and Quarter from 1 to 4, as in DevExtreme.
+const (
+	PartYear      = "Year"
+	PartQuarter   = "Quarter"
+	PartMonth     = "Month"
+	PartDay       = "Day"
+	PartDayOfWeek = "DayOfWeek"
+	PartHour      = "Hour"
+	PartMinute    = "Minute"
+	PartSecond    = "Second"
+)
+
+var datePartsByName = map[string]string{
+	"year":      PartYear,
+	"quarter":   PartQuarter,
+	"month":     PartMonth,
+	"day":       PartDay,
+	"dayofweek": PartDayOfWeek,
+	"hour":      PartHour,
+	"minute":    PartMinute,
+	"second":    PartSecond,
+}
+
+// Type returns the type of the values the condition compares: "int" for a
+// date part, otherwise the field's type.
+func (c *Condition) Type() string {
+	if c.Part != "" {
+		return "int"
+	}
+	return c.Field.Type
+}
+
+// FieldName returns the field as written in a filter, including the date
+// part suffix, e.g. "date.Month".
+func (c *Condition) FieldName() string {
+	if c.Part != "" {
+		return c.Field.Name + "." + c.Part
+	}
+	return c.Field.Name
+}
+
+// DatePart extracts part from t, taken in the configured Timezone, so that
+// parts agree with whole-day and relative date values.
+func DatePart(t time.Time, part string) int {
+	t = t.In(timezone)
+	switch part {
+	case PartYear:
+		return t.Year()
+	case PartQuarter:
+		return (int(t.Month())-1)/3 + 1
+	case PartMonth:
+		return int(t.Month())
+	case PartDay:
+		return t.Day()
+	case PartDayOfWeek:
+		return int(t.Weekday())
+	case PartHour:
+		return t.Hour()
+	case PartMinute:
+		return t.Minute()
+	default:
+		return t.Second()
+	}
+}
+
+// lookupField resolves name case-insensitively in fields, splitting off a
+// date part suffix when name is not a field itself. part is the canonical
+// part name, or "" for a plain field.
+func lookupField(fields map[string]schematool.SchemaFieldDefinition, name string) (field schematool.SchemaFieldDefinition, part string, ok bool) {
+	if field, ok := fields[strings.ToLower(name)]; ok {
+		return field, "", true
+	}
+	dot := strings.LastIndex(name, ".")
+	if dot < 0 {
+		return field, "", false
+	}
+	field, ok = fields[strings.ToLower(name[:dot])]
+	part, isPart := datePartsByName[strings.ToLower(name[dot+1:])]
+	if !ok || !isPart || field.Type != "time.Time" {
+		return field, "", false
+	}
+	return field, part, true
+}
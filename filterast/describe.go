@@ -0,0 +1,133 @@
+package filterast
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// operatorPhrases renders operators for Describe; "between", "anyof" and
+// "noneof" format their values separately.
+var operatorPhrases = map[string]string{
+	"=":           "is",
+	"<>":          "is not",
+	">":           "is greater than",
+	">=":          "is greater than or equal to",
+	"<":           "is less than",
+	"<=":          "is less than or equal to",
+	"contains":    "contains",
+	"notcontains": "does not contain",
+	"startswith":  "starts with",
+	"endswith":    "ends with",
+	"matches":     "matches the pattern",
+	"like":        "is like",
+	"fuzzy":       "is similar to",
+	"between":     "is between",
+	"anyof":       "is any of",
+	"noneof":      "is none of",
+	"isblank":     "is blank",
+	"isnotblank":  "is not blank",
+}
+
+// Describe renders a tree in plain English, e.g. "Amount is greater than 100
+// and Category contains 'food'". Nested groups are parenthesized, and
+// conditions comparing text under a collation other than exact name it.
+func Describe(node Node) string {
+	switch n := node.(type) {
+	case nil:
+		return "all records"
+	case *Condition:
+		return describeCondition(n)
+	case *Not:
+		return "not (" + Describe(n.Child) + ")"
+	case *Group:
+		parts := make([]string, len(n.Children))
+		for i, child := range n.Children {
+			parts[i] = Describe(child)
+			if _, nested := child.(*Group); nested {
+				parts[i] = "(" + parts[i] + ")"
+			}
+		}
+		return strings.Join(parts, " "+string(n.Op)+" ")
+	}
+	return ""
+}
+
+var datePartPhrases = map[string]string{
+	PartYear:      "Year",
+	PartQuarter:   "Quarter",
+	PartMonth:     "Month",
+	PartDay:       "Day",
+	PartDayOfWeek: "Day of week",
+	PartHour:      "Hour",
+	PartMinute:    "Minute",
+	PartSecond:    "Second",
+}
+
+func describeCondition(c *Condition) string {
+	description := describeComparison(c)
+	if c.Collation != "" && c.Collation != CollationExact && c.Operator != "matches" && operatorShapes[c.Operator] != noValue {
+		description += " (" + c.Collation + ")"
+	}
+	return description
+}
+
+func describeComparison(c *Condition) string {
+	subject := FieldCaption(c.Field.Name)
+	if c.Part != "" {
+		subject = datePartPhrases[c.Part] + " of " + subject
+	}
+	subject += " " + operatorPhrases[c.Operator]
+	switch operatorShapes[c.Operator] {
+	case noValue:
+		return subject
+	case rangeValue:
+		return fmt.Sprintf("%s %s and %s", subject, describeValue(c.Values[0]), describeValue(c.Values[1]))
+	case fuzzyOperand:
+		fuzzy := c.Value.(FuzzyValue)
+		return fmt.Sprintf("%s %s (within %d edits)", subject, describeValue(fuzzy.Value), fuzzy.Distance)
+	case listValue:
+		values := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			values[i] = describeValue(v)
+		}
+		return fmt.Sprintf("%s (%s)", subject, strings.Join(values, ", "))
+	}
+	if c.ValueField != nil {
+		ref := FieldCaption(c.ValueField.Field.Name)
+		if c.ValueField.Part != "" {
+			ref = strings.ToLower(datePartPhrases[c.ValueField.Part]) + " of " + ref
+		}
+		return subject + " " + ref
+	}
+	return subject + " " + describeValue(c.Value)
+}
+
+func describeValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "'" + val + "'"
+	case time.Time:
+		// Whole days start at midnight in the configured timezone.
+		if local := val.In(timezone); local.Equal(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, timezone)) {
+			return local.Format("2006-01-02")
+		}
+		return val.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// FieldCaption turns a schema field name such as "retail_price" into the
+// caption DevExtreme would show for it, "Retail Price".
+func FieldCaption(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == ' ' })
+	for i, w := range words {
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
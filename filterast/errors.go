@@ -0,0 +1,55 @@
+package filterast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error codes reported in ValidationError.Code.
+const (
+	CodeInvalidFilter          = "invalid_filter"           // the filter or an operand is not an array
+	CodeMalformedNot           = "malformed_not"            // ["!", ...] without exactly one operand
+	CodeMalformedGroup         = "malformed_group"          // misplaced or dangling logical operator
+	CodeInvalidLogicalOperator = "invalid_logical_operator" // group operator other than "and"/"or"
+	CodeUnknownField           = "unknown_field"            // field not in the schema
+	CodeUnsupportedFieldType   = "unsupported_field_type"   // schema type the engines cannot filter
+	CodeInvalidOperator        = "invalid_operator"         // operator is not a string
+	CodeUnsupportedOperator    = "unsupported_operator"     // operator not allowed for the field type
+	CodeInvalidValue           = "invalid_value"            // value cannot be converted to the field type
+)
+
+// ValidationError describes one problem in a filter. Path addresses the
+// offending element in the DevExtreme array, e.g. "[2][1]" is the operator of
+// the third element; the root array is "".
+type ValidationError struct {
+	Path     string      `json:"path"`
+	Code     string      `json:"code"`
+	Message  string      `json:"message"`
+	Field    string      `json:"field,omitempty"`
+	Operator string      `json:"operator,omitempty"`
+	Value    interface{} `json:"value,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors lists every problem found while parsing a filter. Parse
+// returns it as its error so callers can report all problems at once.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return "invalid filter: " + strings.Join(msgs, "; ")
+}
+
+// indexPath appends an array index to a JSON path.
+func indexPath(path string, i int) string {
+	return fmt.Sprintf("%s[%d]", path, i)
+}
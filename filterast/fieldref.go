@@ -0,0 +1,79 @@
+package filterast
+
+import (
+	"fmt"
+
+	"transaction-filter-backend/schematool"
+)
+
+// fieldRefKey is the key of the object that marks a condition value as a
+// reference to another field, as in ["retail_price", ">", {"field": "cost_price"}].
+var fieldRefKey = "field"
+
+// SetFieldRefKey changes the key that marks a condition value as a field
+// reference. Defaults to "field".
+func SetFieldRefKey(key string) {
+	fieldRefKey = key
+}
+
+// FieldRef is the right-hand side of a field-to-field comparison: another
+// field of the same record, optionally narrowed to a date part.
+type FieldRef struct {
+	Field schematool.SchemaFieldDefinition
+	Part  string
+}
+
+// Type returns the type of the referenced values: "int" for a date part,
+// otherwise the field's type.
+func (r *FieldRef) Type() string {
+	if r.Part != "" {
+		return "int"
+	}
+	return r.Field.Type
+}
+
+// Name returns the reference as written in a filter, e.g. "created_at.Year".
+func (r *FieldRef) Name() string {
+	if r.Part != "" {
+		return r.Field.Name + "." + r.Part
+	}
+	return r.Field.Name
+}
+
+// fieldRefOperators are the operators that accept a field reference.
+var fieldRefOperators = map[string]bool{"=": true, "<>": true, ">": true, ">=": true, "<": true, "<=": true}
+
+// asFieldRef reports whether value is a {"field": name} object and returns
+// the name it refers to.
+func asFieldRef(value interface{}) (name interface{}, ok bool) {
+	obj, isObj := value.(map[string]interface{})
+	if !isObj || len(obj) != 1 {
+		return nil, false
+	}
+	name, ok = obj[fieldRefKey]
+	return name, ok
+}
+
+// resolveFieldRef resolves a field reference for a condition comparing
+// values of valueType. Both sides must have the same type, except that int
+// and float64 mix and so do string and text.
+func resolveFieldRef(fields map[string]schematool.SchemaFieldDefinition, name interface{}, valueType string) (*FieldRef, error) {
+	refName, ok := name.(string)
+	if !ok {
+		return nil, fmt.Errorf("field reference must name a field, got %T", name)
+	}
+	field, part, ok := lookupField(fields, refName)
+	if !ok {
+		return nil, fmt.Errorf("referenced field '%s' not found in schema", refName)
+	}
+	ref := &FieldRef{Field: field, Part: part}
+	if !comparableTypes(valueType, ref.Type()) {
+		return nil, fmt.Errorf("cannot compare %s with %s field %s", valueType, ref.Type(), ref.Name())
+	}
+	return ref, nil
+}
+
+func comparableTypes(a, b string) bool {
+	numeric := func(t string) bool { return t == "int" || t == "float64" }
+	return a == b || (numeric(a) && numeric(b)) || (IsStringType(a) && IsStringType(b))
+}
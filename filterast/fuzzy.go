@@ -0,0 +1,114 @@
+package filterast
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// FuzzyValue is the operand of the "fuzzy" operator, written in a filter as
+// {"value": "Tranzaction", "distance": 2}.
+type FuzzyValue struct {
+	Value    string `json:"value"`
+	Distance int    `json:"distance"`
+}
+
+// DefaultFuzzyDistance is the maximum edit distance used when a fuzzy
+// operand omits "distance".
+const DefaultFuzzyDistance = 2
+
+// NoFuzzyMatch is the distance used when ranking a record whose fuzzy
+// field is null, so that it sorts after every match.
+const NoFuzzyMatch = math.MaxInt32
+
+// coerceFuzzyValue converts the JSON operand of "fuzzy".
+func coerceFuzzyValue(val interface{}) (FuzzyValue, error) {
+	obj, ok := val.(map[string]interface{})
+	if !ok {
+		return FuzzyValue{}, fmt.Errorf(`expected an object {"value": ..., "distance": ...}, got %T`, val)
+	}
+	for key := range obj {
+		if key != "value" && key != "distance" {
+			return FuzzyValue{}, fmt.Errorf("unexpected key '%s'", key)
+		}
+	}
+	text, ok := obj["value"].(string)
+	if !ok || text == "" {
+		return FuzzyValue{}, fmt.Errorf(`"value" must be a non-empty string`)
+	}
+	fuzzy := FuzzyValue{Value: text, Distance: DefaultFuzzyDistance}
+	if raw, ok := obj["distance"]; ok {
+		distance, err := convertToInt(raw)
+		if err != nil || distance < 0 {
+			return FuzzyValue{}, fmt.Errorf(`"distance" must be a non-negative integer, got %v`, raw)
+		}
+		fuzzy.Distance = distance
+	}
+	return fuzzy, nil
+}
+
+// FuzzyDistance returns how many single-character edits (insertions,
+// deletions or substitutions) separate search from value, both compared
+// under collation as by Fold: the Levenshtein distance to the whole value or
+// to the closest run of as many consecutive words as search has, whichever
+// is smaller. A one-word search thus finds "Tranzaction" in
+// "Transaction 42". Both engines use it: the dynamic engine directly and
+// SQLite through the fuzzy_distance function.
+func FuzzyDistance(value, search, collation string) int {
+	value, search = Fold(value, collation), Fold(search, collation)
+	best := levenshtein([]rune(value), []rune(search))
+	words, searchWords := strings.Fields(value), len(strings.Fields(search))
+	if searchWords == 0 {
+		return best
+	}
+	for i := 0; i+searchWords <= len(words); i++ {
+		window := strings.Join(words[i:i+searchWords], " ")
+		if d := levenshtein([]rune(window), []rune(search)); d < best {
+			best = d
+		}
+	}
+	return best
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// FuzzyConditions returns the "fuzzy" conditions of a tree, in filter
+// order, for ranking results by distance.
+func FuzzyConditions(node Node) []*Condition {
+	var conds []*Condition
+	var walk func(n Node)
+	walk = func(n Node) {
+		switch n := n.(type) {
+		case *Condition:
+			if n.Operator == "fuzzy" {
+				conds = append(conds, n)
+			}
+		case *Not:
+			walk(n.Child)
+		case *Group:
+			for _, child := range n.Children {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return conds
+}
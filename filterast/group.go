@@ -0,0 +1,203 @@
+package filterast
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"transaction-filter-backend/schematool"
+)
+
+// GroupField is one level of DevExtreme's group option: records are grouped
+// by a field, one of its date parts, or the field's value rounded down to a
+// multiple of Interval. Groups of strings are keyed by the stored value and
+// ordered by its collation key, then by the stored value.
+type GroupField struct {
+	Field      schematool.SchemaFieldDefinition
+	Part       string
+	Interval   float64 // bucket width of a numeric groupInterval; 0 for none
+	Desc       bool
+	IsExpanded bool   // whether the level's groups list their items
+	Collation  string // the field's collation; empty for other types and buckets
+}
+
+// Type returns the type of the group keys: "float64" for buckets, "int" for
+// a date part, otherwise the field's type.
+func (g GroupField) Type() string {
+	switch {
+	case g.Interval > 0:
+		return "float64"
+	case g.Part != "":
+		return "int"
+	}
+	return g.Field.Type
+}
+
+// ParseGroup reads DevExtreme's group option, a list whose items are either
+// {"selector", "desc", "isExpanded", "groupInterval"} objects or bare field
+// names. A numeric groupInterval buckets int and float64 values; a date part
+// name such as "year" or "month" groups a time.Time field by that part.
+// isExpanded defaults to true. Problems are reported as ValidationErrors
+// addressed by the item's index.
+func ParseGroup(group interface{}, fields map[string]schematool.SchemaFieldDefinition) ([]GroupField, error) {
+	if group == nil {
+		return nil, nil
+	}
+	items, ok := group.([]interface{})
+	if !ok {
+		return nil, ValidationErrors{{Code: CodeInvalidValue, Message: fmt.Sprintf("group must be an array, got %T", group)}}
+	}
+	var errs ValidationErrors
+	result := make([]GroupField, 0, len(items))
+	for i, item := range items {
+		fail := func(code, field, format string, args ...interface{}) {
+			errs = append(errs, &ValidationError{Path: indexPath("", i), Code: code, Field: field, Value: item,
+				Message: fmt.Sprintf(format, args...)})
+		}
+		var selector, interval interface{} = item, nil
+		desc, expanded := false, true
+		if obj, isObj := item.(map[string]interface{}); isObj {
+			selector, interval = obj["selector"], obj["groupInterval"]
+			desc, _ = obj["desc"].(bool)
+			if v, isBool := obj["isExpanded"].(bool); isBool {
+				expanded = v
+			}
+		}
+		name, isStr := selector.(string)
+		if !isStr || name == "" {
+			fail(CodeInvalidValue, "", "group item must be a field name or an object with a \"selector\"")
+			continue
+		}
+		field, part, found := lookupField(fields, name)
+		if !found {
+			fail(CodeUnknownField, name, "group field '%s' not found in schema", name)
+			continue
+		}
+		groupField := GroupField{Field: field, Part: part, Desc: desc, IsExpanded: expanded}
+		switch iv := interval.(type) {
+		case nil:
+		case float64:
+			if t := groupField.Type(); t != "int" && t != "float64" {
+				fail(CodeInvalidValue, name, "numeric groupInterval requires a number field, '%s' is %s", name, t)
+				continue
+			}
+			if iv <= 0 {
+				fail(CodeInvalidValue, name, "groupInterval must be positive, got %v", iv)
+				continue
+			}
+			groupField.Interval = iv
+		case string:
+			datePart, isPart := datePartsByName[strings.ToLower(iv)]
+			if field.Type != "time.Time" || part != "" || !isPart {
+				fail(CodeInvalidValue, name, "groupInterval '%s' requires a date part name and a time.Time field", iv)
+				continue
+			}
+			groupField.Part = datePart
+		default:
+			fail(CodeInvalidValue, name, "groupInterval must be a number or a date part name, got %T", interval)
+			continue
+		}
+		if groupField.Type() == field.Type {
+			groupField.Collation = fieldCollation(field.Type, field.Collation)
+		}
+		result = append(result, groupField)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+// Bucket rounds v down to a multiple of interval, the key of a numeric
+// groupInterval.
+func Bucket(v, interval float64) float64 {
+	return math.Floor(v/interval) * interval
+}
+
+// GroupCount is the number of records sharing one combination of group
+// keys, one key per group level; a nil key is a null value. Aggregates
+// holds the group summaries over those records, if any were asked for.
+type GroupCount struct {
+	Keys       []interface{}
+	Count      int
+	Aggregates []Aggregate
+}
+
+// GroupItem is a group in DevExtreme's grouped load result. Items holds the
+// next level's groups or, at the last level, the group's records; it is nil
+// for a collapsed group. Count is the number of records in the group and
+// Summary the results of the group summaries.
+type GroupItem struct {
+	Key     interface{}   `json:"key"`
+	Items   interface{}   `json:"items"`
+	Count   int           `json:"count"`
+	Summary []interface{} `json:"summary,omitempty"`
+}
+
+// NestGroups builds the group tree from counts that are ordered by their
+// keys, level by level, so records of one group are adjacent, rolling their
+// aggregates up into each group's summary. Levels below a collapsed one are
+// not built.
+func NestGroups(counts []GroupCount, groups []GroupField) []*GroupItem {
+	return nestGroups(counts, groups, 0)
+}
+
+func nestGroups(counts []GroupCount, groups []GroupField, level int) []*GroupItem {
+	var items []*GroupItem
+	for start := 0; start < len(counts); {
+		key := counts[start].Keys[level]
+		end, total := start, 0
+		for ; end < len(counts) && sameGroupKey(counts[end].Keys[level], key); end++ {
+			total += counts[end].Count
+		}
+		item := &GroupItem{Key: key, Count: total}
+		if aggregates := counts[start].Aggregates; len(aggregates) > 0 {
+			merged := make([]Aggregate, len(aggregates))
+			for i, a := range aggregates {
+				merged[i].Type = a.Type
+			}
+			item.Summary = summaryValues(merged, counts[start:end])
+		}
+		if groups[level].IsExpanded && level+1 < len(groups) {
+			item.Items = nestGroups(counts[start:end], groups, level+1)
+		}
+		items = append(items, item)
+		start = end
+	}
+	if items == nil {
+		items = []*GroupItem{}
+	}
+	return items
+}
+
+func sameGroupKey(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return CompareValues(a, b) == 0
+}
+
+// GroupsExpanded reports whether every group level is expanded, in which
+// case the last level's groups list their records.
+func GroupsExpanded(groups []GroupField) bool {
+	for _, g := range groups {
+		if !g.IsExpanded {
+			return false
+		}
+	}
+	return len(groups) > 0
+}
+
+// FillGroups hands out records, ordered like the groups, to the last-level
+// groups under items, each taking as many as it counts.
+func FillGroups(items []*GroupItem, records []map[string]interface{}) []map[string]interface{} {
+	for _, item := range items {
+		if children, ok := item.Items.([]*GroupItem); ok {
+			records = FillGroups(children, records)
+			continue
+		}
+		n := min(item.Count, len(records))
+		item.Items, records = records[:n], records[n:]
+	}
+	return records
+}
@@ -0,0 +1,348 @@
+package filterast
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"transaction-filter-backend/schematool"
+)
+
+// negatedOperators maps each operator to its exact complement. Every pair
+// keeps SQL's treatment of NULL: neither side matches a NULL column.
+var negatedOperators = map[string]string{
+	"=":           "<>",
+	"<>":          "=",
+	">":           "<=",
+	"<=":          ">",
+	"<":           ">=",
+	">=":          "<",
+	"contains":    "notcontains",
+	"notcontains": "contains",
+	"anyof":       "noneof",
+	"noneof":      "anyof",
+	"isblank":     "isnotblank",
+	"isnotblank":  "isblank",
+}
+
+// NormalizeFilter parses a DevExtreme filter, simplifies it with Normalize
+// and renders the result back as a canonical DevExtreme array (nil when the
+// filter matches everything).
+func NormalizeFilter(filter interface{}, fields map[string]schematool.SchemaFieldDefinition) (interface{}, error) {
+	node, err := Parse(filter, fields)
+	if err != nil {
+		return nil, err
+	}
+	return ToFilterArray(Normalize(node)), nil
+}
+
+// Normalize returns an equivalent, simplified tree: double negations are
+// removed, NOT is pushed down to the conditions (De Morgan), nested groups
+// with the same operator are flattened, duplicate children are dropped and
+// range conditions AND-ed on the same field are merged into the tightest
+// bounds, using "between" when both bounds are inclusive.
+func Normalize(node Node) Node {
+	return simplify(pushNot(node, false))
+}
+
+// pushNot moves negations down to the conditions, flipping AND/OR on the
+// way. Conditions whose operator has no exact complement stay wrapped in Not.
+func pushNot(node Node, negate bool) Node {
+	switch n := node.(type) {
+	case *Not:
+		return pushNot(n.Child, !negate)
+	case *Group:
+		op := n.Op
+		if negate {
+			op = And
+			if n.Op == And {
+				op = Or
+			}
+		}
+		children := make([]Node, len(n.Children))
+		for i, child := range n.Children {
+			children[i] = pushNot(child, negate)
+		}
+		return &Group{Op: op, Children: children}
+	case *Condition:
+		if !negate {
+			return n
+		}
+		if negated, ok := negatedOperators[n.Operator]; ok {
+			c := *n
+			c.Operator = negated
+			return &c
+		}
+		return &Not{Child: n}
+	}
+	return node
+}
+
+func simplify(node Node) Node {
+	g, ok := node.(*Group)
+	if !ok {
+		return node
+	}
+	children := make([]Node, len(g.Children))
+	for i, child := range g.Children {
+		children[i] = simplify(child)
+	}
+	// newGroup lifts same-operator children so merging sees all siblings.
+	grouped := newGroup(g.Op, children)
+	flat, ok := grouped.(*Group)
+	if !ok {
+		return grouped
+	}
+	children = flat.Children
+	if g.Op == And {
+		children = mergeRanges(children)
+	}
+	return newGroup(g.Op, dedupe(children))
+}
+
+// dedupe drops children that render to the same DevExtreme array as an
+// earlier sibling. Relative date expressions are compared as written, since
+// "today" and the date it resolves to now are only equal until tomorrow.
+func dedupe(children []Node) []Node {
+	seen := make(map[string]bool, len(children))
+	unique := make([]Node, 0, len(children))
+	for _, child := range children {
+		key, err := json.Marshal(ToFilterArray(child))
+		if err == nil {
+			if seen[string(key)] {
+				continue
+			}
+			seen[string(key)] = true
+		}
+		unique = append(unique, child)
+	}
+	return unique
+}
+
+// rangeBound is one side of a merged range; inclusive is false for > and <.
+// raw is the bound as written, kept for rendering relative dates.
+type rangeBound struct {
+	value     interface{}
+	raw       interface{}
+	inclusive bool
+}
+
+// fieldRange collects the tightest bounds AND-ed on one ordered field.
+type fieldRange struct {
+	field        schematool.SchemaFieldDefinition
+	part         string
+	lower, upper *rangeBound
+	position     int // index of the first merged condition, to keep order stable
+}
+
+func (r *fieldRange) tightenLower(b rangeBound) {
+	if r.lower == nil {
+		r.lower = &b
+		return
+	}
+	bv, bIncl := effectiveBound(b, true)
+	rv, _ := effectiveBound(*r.lower, true)
+	c := CompareValues(bv, rv)
+	if c > 0 || (c == 0 && !bIncl) {
+		r.lower = &b
+	}
+}
+
+func (r *fieldRange) tightenUpper(b rangeBound) {
+	if r.upper == nil {
+		r.upper = &b
+		return
+	}
+	bv, bIncl := effectiveBound(b, false)
+	rv, _ := effectiveBound(*r.upper, false)
+	c := CompareValues(bv, rv)
+	if c < 0 || (c == 0 && !bIncl) {
+		r.upper = &b
+	}
+}
+
+func (r *fieldRange) conditions() []Node {
+	if r.lower != nil && r.upper != nil && r.lower.inclusive && r.upper.inclusive {
+		return []Node{&Condition{
+			Field: r.field, Part: r.part, Operator: "between",
+			Values: []interface{}{r.lower.value, r.upper.value},
+			Raw:    []interface{}{r.lower.raw, r.upper.raw},
+		}}
+	}
+	var nodes []Node
+	if r.lower != nil {
+		op := ">"
+		if r.lower.inclusive {
+			op = ">="
+		}
+		nodes = append(nodes, &Condition{Field: r.field, Part: r.part, Operator: op, Value: r.lower.value, Raw: r.lower.raw})
+	}
+	if r.upper != nil {
+		op := "<"
+		if r.upper.inclusive {
+			op = "<="
+		}
+		nodes = append(nodes, &Condition{Field: r.field, Part: r.part, Operator: op, Value: r.upper.value, Raw: r.upper.raw})
+	}
+	return nodes
+}
+
+// mergeRanges replaces the >, >=, <, <= and between conditions on each field
+// of an AND group with the tightest equivalent bounds. Conditions on relative
+// date expressions are left alone: which bound is tighter depends on when the
+// filter runs, and the normalized filter keeps the expression.
+func mergeRanges(children []Node) []Node {
+	ranges := make(map[string]*fieldRange)
+	var order []*fieldRange
+	var rest []Node
+	restPositions := make([]int, 0, len(children))
+	for i, child := range children {
+		cond, ok := child.(*Condition)
+		if !ok || cond.ValueField != nil || cond.Type() == "bool" || IsStringType(cond.Type()) || hasRelativeValue(cond) {
+			rest = append(rest, child)
+			restPositions = append(restPositions, i)
+			continue
+		}
+		var lower, upper *rangeBound
+		switch cond.Operator {
+		case ">", ">=":
+			lower = &rangeBound{value: cond.Value, raw: cond.Raw, inclusive: cond.Operator == ">="}
+		case "<", "<=":
+			upper = &rangeBound{value: cond.Value, raw: cond.Raw, inclusive: cond.Operator == "<="}
+		case "between":
+			raw, _ := cond.Raw.([]interface{})
+			lower = &rangeBound{value: cond.Values[0], raw: rawAt(raw, 0), inclusive: true}
+			upper = &rangeBound{value: cond.Values[1], raw: rawAt(raw, 1), inclusive: true}
+		default:
+			rest = append(rest, child)
+			restPositions = append(restPositions, i)
+			continue
+		}
+		key := strings.ToLower(cond.FieldName())
+		r, exists := ranges[key]
+		if !exists {
+			r = &fieldRange{field: cond.Field, part: cond.Part, position: i}
+			ranges[key] = r
+			order = append(order, r)
+		}
+		if lower != nil {
+			r.tightenLower(*lower)
+		}
+		if upper != nil {
+			r.tightenUpper(*upper)
+		}
+	}
+	if len(order) == 0 {
+		return children
+	}
+	// Re-interleave merged ranges with the other children by first position.
+	merged := make([]Node, 0, len(children))
+	ri := 0
+	for _, r := range order {
+		for ri < len(rest) && restPositions[ri] < r.position {
+			merged = append(merged, rest[ri])
+			ri++
+		}
+		merged = append(merged, r.conditions()...)
+	}
+	return append(merged, rest[ri:]...)
+}
+
+// hasRelativeValue reports whether any operand of cond was written as a
+// relative date expression.
+func hasRelativeValue(cond *Condition) bool {
+	if cond.Type() != "time.Time" {
+		return false
+	}
+	raws, ok := cond.Raw.([]interface{})
+	if !ok {
+		raws = []interface{}{cond.Raw}
+	}
+	for _, raw := range raws {
+		if s, ok := raw.(string); ok {
+			if _, ok := resolveRelativeTime(s); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ToFilterArray renders a tree as a DevExtreme filter array. Conditions use
+// the schema's field names and their coerced values, except that date-only
+// values and relative date expressions are written back as given; a nil node
+// renders as nil.
+func ToFilterArray(node Node) interface{} {
+	switch n := node.(type) {
+	case *Condition:
+		switch operatorShapes[n.Operator] {
+		case listValue, rangeValue:
+			raw, _ := n.Raw.([]interface{})
+			values := make([]interface{}, len(n.Values))
+			for i, v := range n.Values {
+				values[i] = renderValue(n.Type(), rawAt(raw, i), v)
+			}
+			return []interface{}{n.FieldName(), n.Operator, values}
+		case fuzzyOperand:
+			fuzzy := n.Value.(FuzzyValue)
+			return []interface{}{n.FieldName(), n.Operator, map[string]interface{}{"value": fuzzy.Value, "distance": fuzzy.Distance}}
+		}
+		if n.ValueField != nil {
+			return []interface{}{n.FieldName(), n.Operator, map[string]interface{}{fieldRefKey: n.ValueField.Name()}}
+		}
+		return []interface{}{n.FieldName(), n.Operator, renderValue(n.Type(), n.Raw, n.Value)}
+	case *Not:
+		return []interface{}{"!", ToFilterArray(n.Child)}
+	case *Group:
+		arr := make([]interface{}, 0, 2*len(n.Children)-1)
+		for i, child := range n.Children {
+			if i > 0 {
+				arr = append(arr, string(n.Op))
+			}
+			arr = append(arr, ToFilterArray(child))
+		}
+		return arr
+	}
+	return nil
+}
+
+// rawAt returns raw[i], or nil when raw is shorter.
+func rawAt(raw []interface{}, i int) interface{} {
+	if i < len(raw) {
+		return raw[i]
+	}
+	return nil
+}
+
+// CompareValues orders two coerced values of the same field type, returning
+// -1, 0 or 1. Strings compare byte-wise; for bools false sorts before true.
+func CompareValues(a, b interface{}) int {
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, b.(string))
+	case int:
+		return compareOrdered(av, b.(int))
+	case float64:
+		return compareOrdered(av, b.(float64))
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		} else if !av {
+			return -1
+		}
+		return 1
+	case time.Time:
+		return av.Compare(b.(time.Time))
+	}
+	return 1 // not reached for coerced values; treat unknown types as unequal
+}
+
+func compareOrdered[T int | float64](a, b T) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,140 @@
+package filterast
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeFilter(t *testing.T) {
+	SetClock(func() time.Time { return time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC) })
+	defer SetClock(time.Now)
+
+	testCases := []struct {
+		name     string
+		filter   string
+		expected string
+	}{
+		{
+			name:     "double negation",
+			filter:   `["!", ["!", ["qty", "=", 1]]]`,
+			expected: `["qty","=",1]`,
+		},
+		{
+			name:     "De Morgan pushes NOT into the conditions",
+			filter:   `["!", [["qty", ">", 1], "and", ["name", "contains", "a"]]]`,
+			expected: `[["qty","<=",1],"or",["name","notcontains","a"]]`,
+		},
+		{
+			name:     "NOT stays around operators without a complement",
+			filter:   `["!", ["name", "startswith", "a"]]`,
+			expected: `["!",["name","startswith","a"]]`,
+		},
+		{
+			name:     "nested single-element groups",
+			filter:   `[[[["qty", "=", 1]]]]`,
+			expected: `["qty","=",1]`,
+		},
+		{
+			name:     "nested groups with the same operator are flattened",
+			filter:   `[[["qty", "=", 1], "or", ["qty", "=", 2]], "or", ["!", ["!", [["qty", "=", 3], "or", ["qty", "=", 4]]]]]`,
+			expected: `[["qty","=",1],"or",["qty","=",2],"or",["qty","=",3],"or",["qty","=",4]]`,
+		},
+		{
+			name:     "duplicate conditions",
+			filter:   `[["name", "a"], "and", ["name", "=", "a"], "and", ["qty", "=", 1]]`,
+			expected: `[["name","=","a"],"and",["qty","=",1]]`,
+		},
+		{
+			name:     "tightest lower bound wins",
+			filter:   `[["qty", ">", 5], "and", ["qty", ">", 10], "and", ["qty", ">=", 10]]`,
+			expected: `["qty",">",10]`,
+		},
+		{
+			name:     "inclusive bounds merge into between",
+			filter:   `[["price", ">=", 1], "and", ["name", "=", "a"], "and", ["price", "<=", 5], "and", ["price", "between", [0, 4]]]`,
+			expected: `[["price","between",[1,4]],"and",["name","=","a"]]`,
+		},
+		{
+			name:     "ranges are not merged across OR",
+			filter:   `[["qty", ">", 5], "or", ["qty", ">", 10]]`,
+			expected: `[["qty",">",5],"or",["qty",">",10]]`,
+		},
+		{
+			name:     "date-only values are kept as written",
+			filter:   `[["due", ">=", "2024-01-05"], "and", ["due", "<=", "2024-01-07"]]`,
+			expected: `["due","between",["2024-01-05","2024-01-07"]]`,
+		},
+		{
+			name:     "date-only upper bound covers its whole day",
+			filter:   `[["due", "<=", "2024-01-05"], "and", ["due", "<", "2024-01-05T12:00:00Z"], "and", ["due", ">", "2024-01-03"], "and", ["due", ">=", "2024-01-03T18:00:00Z"]]`,
+			expected: `[["due",">","2024-01-03"],"and",["due","<","2024-01-05T12:00:00Z"]]`,
+		},
+		{
+			name:     "date part ranges merge per part",
+			filter:   `[["DUE.month", ">=", 10], "and", ["due.Month", "<=", 12], "and", ["due.Year", ">", 2020], "and", ["due", ">", "2024-01-01T00:00:00Z"]]`,
+			expected: `[["due.Month","between",[10,12]],"and",["due.Year",">",2020],"and",["due",">","2024-01-01T00:00:00Z"]]`,
+		},
+		{
+			name:     "field comparisons are kept and negated",
+			filter:   `[["!", ["qty", ">", {"field": "PRICE"}]], "and", ["qty", "<", 5]]`,
+			expected: `[["qty","<=",{"field":"price"}],"and",["qty","<",5]]`,
+		},
+		{
+			name:     "relative bounds are not merged with fixed ones",
+			filter:   `[["due", ">", "now-7d"], "and", ["due", ">", "2030-01-01"]]`,
+			expected: `[["due",">","now-7d"],"and",["due",">","2030-01-01"]]`,
+		},
+		{
+			name:     "a relative value is not a duplicate of the date it resolves to",
+			filter:   `[["due", "=", "today"], "or", ["due", "=", "2026-10-16T00:00:00Z"]]`,
+			expected: `[["due","=","today"],"or",["due","=","2026-10-16T00:00:00Z"]]`,
+		},
+		{
+			name:     "a relative bound does not absorb an equal fixed bound",
+			filter:   `[["due", ">=", "today"], "and", ["due", ">=", "2026-10-16T00:00:00Z"]]`,
+			expected: `[["due",">=","today"],"and",["due",">=","2026-10-16T00:00:00Z"]]`,
+		},
+		{
+			name:     "empty filter",
+			filter:   `[]`,
+			expected: `null`,
+		},
+		{
+			name:     "an empty group in an AND is dropped",
+			filter:   `[["name", "=", "x"], "and", []]`,
+			expected: `["name","=","x"]`,
+		},
+		{
+			name:     "an empty group in an OR matches everything",
+			filter:   `[["name", "=", "x"], "or", []]`,
+			expected: `null`,
+		},
+		{
+			name:     "an OR matching everything drops out of the enclosing AND",
+			filter:   `[["qty", "=", 1], "and", [["name", "=", "x"], "or", []]]`,
+			expected: `["qty","=",1]`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var filter interface{}
+			if err := json.Unmarshal([]byte(tc.filter), &filter); err != nil {
+				t.Fatalf("bad test filter: %v", err)
+			}
+			normalized, err := NormalizeFilter(filter, testFields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.Encode(normalized)
+			if got := strings.TrimSpace(buf.String()); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
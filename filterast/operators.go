@@ -0,0 +1,224 @@
+package filterast
+
+import (
+	"fmt"
+	"strings"
+
+	"transaction-filter-backend/schematool"
+)
+
+// valueShape describes what kind of operand an operator takes.
+type valueShape int
+
+const (
+	scalarValue  valueShape = iota // a single value of the field's type
+	listValue                      // an array of values of the field's type
+	rangeValue                     // an array of exactly two values: [lower, upper]
+	noValue                        // the operand is ignored
+	fuzzyOperand                   // a {"value", "distance"} object, see FuzzyValue
+)
+
+var operatorShapes = map[string]valueShape{
+	"=":           scalarValue,
+	"<>":          scalarValue,
+	">":           scalarValue,
+	">=":          scalarValue,
+	"<":           scalarValue,
+	"<=":          scalarValue,
+	"contains":    scalarValue,
+	"notcontains": scalarValue,
+	"startswith":  scalarValue,
+	"endswith":    scalarValue,
+	"matches":     scalarValue,
+	"like":        scalarValue,
+	"fuzzy":       fuzzyOperand,
+	"between":     rangeValue,
+	"anyof":       listValue,
+	"noneof":      listValue,
+	"isblank":     noValue,
+	"isnotblank":  noValue,
+}
+
+var (
+	commonOperators  = []string{"=", "<>", "anyof", "noneof", "isblank", "isnotblank"}
+	orderedOperators = append([]string{">", ">=", "<", "<=", "between"}, commonOperators...)
+	stringOperators  = append([]string{"contains", "notcontains", "startswith", "endswith", "matches", "like", "fuzzy"}, commonOperators...)
+)
+
+// operatorsByType lists the operators each schema field type supports. Both
+// backends must implement every operator listed here.
+var operatorsByType = map[string][]string{
+	"string":    stringOperators,
+	"text":      stringOperators,
+	"int":       orderedOperators,
+	"float64":   orderedOperators,
+	"time.Time": orderedOperators,
+	"bool":      commonOperators,
+}
+
+// OperatorsForType returns the operators allowed on fields of fieldType, or
+// nil for an unsupported type.
+func OperatorsForType(fieldType string) []string {
+	return operatorsByType[fieldType]
+}
+
+// IsStringType reports whether fieldType holds text.
+func IsStringType(fieldType string) bool {
+	return fieldType == "string" || fieldType == "text"
+}
+
+func operatorAllowed(fieldType, op string) bool {
+	for _, allowed := range operatorsByType[fieldType] {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// NewCondition resolves field in fields, with an optional date part suffix
+// such as ".Month" on time.Time fields, validates op for the field's type
+// and coerces value into the shape and type the operator expects.
+func NewCondition(fields map[string]schematool.SchemaFieldDefinition, field, op string, value interface{}) (*Condition, error) {
+	cond, errs := newCondition(fields, field, op, value, "", 2)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return cond, nil
+}
+
+// newCondition is NewCondition for a condition found at path in a filter;
+// valueIndex is the position of the value within the condition array, which
+// is 1 for the ["field", value] shorthand.
+func newCondition(fields map[string]schematool.SchemaFieldDefinition, field, op string, value interface{}, path string, valueIndex int) (*Condition, ValidationErrors) {
+	fail := func(elem int, code string, val interface{}, format string, args ...interface{}) ValidationErrors {
+		return ValidationErrors{{
+			Path: indexPath(path, elem), Code: code, Field: field, Operator: op, Value: val,
+			Message: fmt.Sprintf(format, args...),
+		}}
+	}
+	fieldSchema, part, ok := lookupField(fields, field)
+	if !ok {
+		return nil, fail(0, CodeUnknownField, nil, "field '%s' not found in schema", field)
+	}
+	cond := &Condition{Field: fieldSchema, Part: part, Operator: strings.ToLower(op), Raw: value}
+	valueType, opLower := cond.Type(), cond.Operator
+	cond.Collation = fieldCollation(valueType, fieldSchema.Collation)
+	if _, ok := operatorsByType[valueType]; !ok {
+		return nil, fail(0, CodeUnsupportedFieldType, nil, "unsupported field type '%s' for field '%s'", valueType, field)
+	}
+	if !operatorAllowed(valueType, opLower) {
+		return nil, fail(1, CodeUnsupportedOperator, nil, "unsupported operator '%s' for field type %s of field %s", op, valueType, field)
+	}
+
+	switch operatorShapes[opLower] {
+	case noValue:
+		cond.Raw = nil
+	case scalarValue:
+		if name, isRef := asFieldRef(value); isRef {
+			if !fieldRefOperators[opLower] {
+				return nil, fail(valueIndex, CodeInvalidValue, value, "operator '%s' cannot compare field %s with another field", op, field)
+			}
+			ref, err := resolveFieldRef(fields, name, valueType)
+			if err != nil {
+				return nil, fail(valueIndex, CodeInvalidValue, value, "invalid field reference for field %s: %v", field, err)
+			}
+			cond.ValueField = ref
+			return cond, nil
+		}
+		if value == nil {
+			if opLower != "=" && opLower != "<>" {
+				return nil, fail(valueIndex, CodeInvalidValue, nil, "operator '%s' cannot compare field %s with null", op, field)
+			}
+			return cond, nil
+		}
+		converted, err := coerceFilterValue(valueType, value)
+		if err != nil {
+			return nil, fail(valueIndex, CodeInvalidValue, value, "invalid value for %s field %s: %v", valueType, field, err)
+		}
+		if opLower == "matches" {
+			if _, err := CompilePattern(converted.(string)); err != nil {
+				return nil, fail(valueIndex, CodeInvalidValue, value, "invalid regular expression for field %s: %v", field, err)
+			}
+		}
+		cond.Value = converted
+	case fuzzyOperand:
+		fuzzy, err := coerceFuzzyValue(value)
+		if err != nil {
+			return nil, fail(valueIndex, CodeInvalidValue, value, "invalid value for '%s' on field %s: %v", op, field, err)
+		}
+		cond.Value = fuzzy
+	case listValue, rangeValue:
+		valueSlice, ok := value.([]interface{})
+		if !ok {
+			return nil, fail(valueIndex, CodeInvalidValue, value, "operator '%s' requires an array of values, got %T for field %s", op, value, field)
+		}
+		if operatorShapes[opLower] == rangeValue && len(valueSlice) != 2 {
+			return nil, fail(valueIndex, CodeInvalidValue, value, "operator '%s' requires an array of two values, got %d for field %s", op, len(valueSlice), field)
+		}
+		var errs ValidationErrors
+		cond.Values = make([]interface{}, 0, len(valueSlice))
+		for i, item := range valueSlice {
+			converted, err := coerceFilterValue(valueType, item)
+			if err != nil {
+				errs = append(errs, &ValidationError{
+					Path: indexPath(indexPath(path, valueIndex), i), Code: CodeInvalidValue, Field: field, Operator: op, Value: item,
+					Message: fmt.Sprintf("invalid value at index %d for '%s' on field %s: %v", i, op, field, err),
+				})
+				continue
+			}
+			cond.Values = append(cond.Values, converted)
+		}
+		if len(errs) > 0 {
+			return nil, errs
+		}
+	}
+	return cond, nil
+}
+
+// CoerceValue converts a raw JSON value to the Go type stored for fieldType:
+// string, int, float64, bool or time.Time.
+func CoerceValue(fieldType string, val interface{}) (interface{}, error) {
+	switch fieldType {
+	case "string", "text":
+		strVal, ok := val.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, got %T", val)
+		}
+		return strVal, nil
+	case "int":
+		return convertToInt(val)
+	case "float64":
+		return convertToFloat64(val)
+	case "bool":
+		return convertToBool(val)
+	case "time.Time":
+		return convertToTime(val)
+	default:
+		return nil, fmt.Errorf("unsupported field type '%s'", fieldType)
+	}
+}
+
+// RecordValue converts a stored, non-null value of a dynamic table record or
+// computed field operand to the Go type used for fieldType, following
+// SQLite's column affinities: any value of a string field reads as text, and
+// a fractional value in an int field stays a float64 rather than being
+// truncated. ok is false when the value cannot be converted.
+func RecordValue(fieldType string, raw interface{}) (interface{}, bool) {
+	switch fieldType {
+	case "string", "text":
+		return fmt.Sprintf("%v", raw), true
+	case "int":
+		f, err := CoerceValue("float64", raw)
+		if err != nil {
+			return nil, false
+		}
+		if v := f.(float64); v == float64(int(v)) {
+			return int(v), true
+		}
+		return f, true
+	default:
+		v, err := CoerceValue(fieldType, raw)
+		return v, err == nil
+	}
+}
@@ -0,0 +1,75 @@
+package filterast
+
+import (
+	"container/list"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// patternCacheSize bounds the number of compiled patterns kept, since the
+// patterns come from clients and SQLite asks for one per row.
+const patternCacheSize = 256
+
+// patternCache keeps the most recently used compiled patterns.
+var patternCache = struct {
+	sync.Mutex
+	order   *list.List               // of *patternEntry, most recent first
+	entries map[string]*list.Element // pattern -> element of order
+}{order: list.New(), entries: make(map[string]*list.Element)}
+
+type patternEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+// CompilePattern compiles an RE2 pattern for the "matches" operator,
+// caching the result so that engines evaluating a condition row by row
+// compile each pattern once. Only the patternCacheSize most recently used
+// patterns are kept.
+func CompilePattern(pattern string) (*regexp.Regexp, error) {
+	cache := &patternCache
+	cache.Lock()
+	if elem, ok := cache.entries[pattern]; ok {
+		cache.order.MoveToFront(elem)
+		cache.Unlock()
+		return elem.Value.(*patternEntry).re, nil
+	}
+	cache.Unlock()
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	if _, ok := cache.entries[pattern]; !ok {
+		cache.entries[pattern] = cache.order.PushFront(&patternEntry{pattern, re})
+		if cache.order.Len() > patternCacheSize {
+			oldest := cache.order.Remove(cache.order.Back()).(*patternEntry)
+			delete(cache.entries, oldest.pattern)
+		}
+	}
+	return re, nil
+}
+
+// LikePattern converts a SQL LIKE pattern, where % matches any run of
+// characters and _ exactly one, into an equivalent RE2 pattern matching the
+// whole value. It is case-sensitive: engines apply the condition's collation
+// by matching it against folded text, see Fold.
+func LikePattern(like string) string {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for _, r := range like {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
@@ -0,0 +1,132 @@
+package filterast
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	clock    = time.Now
+	timezone = time.UTC
+)
+
+// SetClock replaces the function used as "now" when resolving relative date
+// tokens in filter values.
+func SetClock(now func() time.Time) {
+	clock = now
+}
+
+// SetTimezone sets the timezone in which calendar-based tokens such as
+// "today" and "startOfMonth" are computed. Defaults to UTC.
+func SetTimezone(loc *time.Location) {
+	timezone = loc
+}
+
+// Timezone returns the timezone used for relative date tokens.
+func Timezone() *time.Location {
+	return timezone
+}
+
+var (
+	relativeTimePattern   = regexp.MustCompile(`^([A-Za-z]+)((?:\s*[+-]\s*\d+\s*[smhdwMy])*)$`)
+	relativeOffsetPattern = regexp.MustCompile(`([+-])\s*(\d+)\s*([smhdwMy])`)
+)
+
+// resolveRelativeTime resolves a relative date expression such as "now",
+// "today", "now-7d" or "startOfYear-1y" against the configured clock and
+// timezone. The base is one of now, today, yesterday, tomorrow, startOfWeek
+// (Monday), startOfMonth, startOfQuarter or startOfYear, matched
+// case-insensitively, followed by any number of signed offsets with units
+// s, m, h, d, w, M (months) or y. ok is false when s is not such an
+// expression. The result is in UTC, the timezone stored values use.
+func resolveRelativeTime(s string) (t time.Time, ok bool) {
+	m := relativeTimePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return time.Time{}, false
+	}
+	now := clock().In(timezone)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, timezone)
+	switch strings.ToLower(m[1]) {
+	case "now":
+		t = now
+	case "today":
+		t = today
+	case "yesterday":
+		t = today.AddDate(0, 0, -1)
+	case "tomorrow":
+		t = today.AddDate(0, 0, 1)
+	case "startofweek":
+		t = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	case "startofmonth":
+		t = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, timezone)
+	case "startofquarter":
+		t = time.Date(now.Year(), now.Month()-(now.Month()-1)%3, 1, 0, 0, 0, 0, timezone)
+	case "startofyear":
+		t = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, timezone)
+	default:
+		return time.Time{}, false
+	}
+	for _, offset := range relativeOffsetPattern.FindAllStringSubmatch(m[2], -1) {
+		n, _ := strconv.Atoi(offset[2])
+		if offset[1] == "-" {
+			n = -n
+		}
+		switch offset[3] {
+		case "s":
+			t = t.Add(time.Duration(n) * time.Second)
+		case "m":
+			t = t.Add(time.Duration(n) * time.Minute)
+		case "h":
+			t = t.Add(time.Duration(n) * time.Hour)
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "M":
+			t = t.AddDate(0, n, 0)
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+	return t.UTC(), true
+}
+
+// coerceFilterValue is CoerceValue for values written in a filter, where
+// time.Time fields also accept relative date expressions and date-only
+// values resolve to midnight in the configured timezone.
+func coerceFilterValue(fieldType string, val interface{}) (interface{}, error) {
+	if fieldType == "time.Time" {
+		if s, ok := val.(string); ok {
+			if t, ok := resolveRelativeTime(s); ok {
+				return t, nil
+			}
+			if isDateOnly(s) {
+				t, err := time.ParseInLocation("2006-01-02", s, timezone)
+				if err != nil {
+					return nil, err
+				}
+				return t.UTC(), nil
+			}
+		}
+	}
+	return CoerceValue(fieldType, val)
+}
+
+// renderValue returns the operand to write back into a filter array: the
+// value as written when it was a date-only value (which means the whole
+// day) or a relative expression; otherwise the coerced value.
+func renderValue(fieldType string, raw, coerced interface{}) interface{} {
+	if fieldType == "time.Time" {
+		if s, ok := raw.(string); ok {
+			if isDateOnly(s) {
+				return s
+			}
+			if _, ok := resolveRelativeTime(s); ok {
+				return s
+			}
+		}
+	}
+	return coerced
+}
@@ -0,0 +1,127 @@
+package filterast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveRelativeTime(t *testing.T) {
+	// Wednesday 2024-05-15 22:30 in UTC is already Thursday in Tokyo (UTC+9).
+	fixedNow := time.Date(2024, time.May, 15, 22, 30, 0, 0, time.UTC)
+	SetClock(func() time.Time { return fixedNow })
+	defer SetClock(time.Now)
+	tokyo := time.FixedZone("UTC+9", 9*60*60)
+
+	testCases := []struct {
+		expr     string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{"now", time.UTC, fixedNow},
+		{"now-7d", time.UTC, fixedNow.AddDate(0, 0, -7)},
+		{"NOW + 2h - 30m", time.UTC, fixedNow.Add(90 * time.Minute)},
+		{"today", time.UTC, time.Date(2024, time.May, 15, 0, 0, 0, 0, time.UTC)},
+		{"today", tokyo, time.Date(2024, time.May, 15, 15, 0, 0, 0, time.UTC)},
+		{"yesterday", time.UTC, time.Date(2024, time.May, 14, 0, 0, 0, 0, time.UTC)},
+		{"startOfWeek", time.UTC, time.Date(2024, time.May, 13, 0, 0, 0, 0, time.UTC)},
+		{"startOfMonth-1M", time.UTC, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"startOfQuarter", time.UTC, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"startOfYear-1y", time.UTC, time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr+" "+tc.loc.String(), func(t *testing.T) {
+			SetTimezone(tc.loc)
+			defer SetTimezone(time.UTC)
+			got, ok := resolveRelativeTime(tc.expr)
+			if !ok {
+				t.Fatalf("expected %q to resolve", tc.expr)
+			}
+			if !got.Equal(tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	for _, expr := range []string{"2024-01-01", "someday", "now-7x", "now 7d"} {
+		if _, ok := resolveRelativeTime(expr); ok {
+			t.Errorf("expected %q not to be a relative expression", expr)
+		}
+	}
+}
+
+func TestRelativeTimeSurvivesNormalization(t *testing.T) {
+	var filter interface{}
+	json.Unmarshal([]byte(`[["due", ">=", "now-30d"], "and", ["due", "<=", "today"], "and", ["due", ">", "2000-01-01"]]`), &filter)
+	normalized, err := NormalizeFilter(filter, testFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(normalized)
+	if got := strings.TrimSpace(buf.String()); got != `[["due",">=","now-30d"],"and",["due","<=","today"],"and",["due",">","2000-01-01"]]` {
+		t.Errorf("expected relative bounds to be kept unmerged, got %s", got)
+	}
+}
+
+func TestExpandWholeDay(t *testing.T) {
+	plusFive := time.FixedZone("UTC+5", 5*60*60)
+	SetTimezone(plusFive)
+	defer SetTimezone(time.UTC)
+
+	start := time.Date(2024, time.January, 5, 0, 0, 0, 0, plusFive).UTC()
+	next := start.AddDate(0, 0, 1)
+	testCases := []struct {
+		filter   string
+		expected string
+	}{
+		{`["due", "=", "2024-01-05"]`, fmt.Sprintf(`[["due",">=",%q],"and",["due","<",%q]]`, stamp(start), stamp(next))},
+		{`["due", "<>", "2024-01-05"]`, fmt.Sprintf(`[["due","<",%q],"or",["due",">=",%q]]`, stamp(start), stamp(next))},
+		{`["due", ">", "2024-01-05"]`, fmt.Sprintf(`["due",">=",%q]`, stamp(next))},
+		{`["due", "<=", "2024-01-05"]`, fmt.Sprintf(`["due","<",%q]`, stamp(next))},
+		{`["due", "between", ["2024-01-04T10:00:00Z", "2024-01-05"]]`, fmt.Sprintf(`[["due",">=","2024-01-04T10:00:00Z"],"and",["due","<",%q]]`, stamp(next))},
+		{`["due", "noneof", ["2024-01-05", "2024-01-04T10:00:00Z"]]`, fmt.Sprintf(`[[["due","<",%q],"or",["due",">=",%q]],"and",["due","<>","2024-01-04T10:00:00Z"]]`, stamp(start), stamp(next))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.filter, func(t *testing.T) {
+			var filter interface{}
+			json.Unmarshal([]byte(tc.filter), &filter)
+			node, err := Parse(filter, testFields)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expanded, ok := ExpandWholeDay(node.(*Condition))
+			if !ok {
+				t.Fatalf("expected %s to be expanded", tc.filter)
+			}
+			var got bytes.Buffer
+			enc := json.NewEncoder(&got)
+			enc.SetEscapeHTML(false)
+			enc.Encode(ToFilterArray(expanded))
+			if strings.TrimSpace(got.String()) != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got.String())
+			}
+		})
+	}
+
+	for _, filter := range []string{`["due", "<", "2024-01-05"]`, `["due", ">=", "2024-01-05"]`, `["due", "=", "2024-01-05T00:00:00Z"]`, `["due", "=", "today"]`} {
+		var f interface{}
+		json.Unmarshal([]byte(filter), &f)
+		node, err := Parse(f, testFields)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, ok := ExpandWholeDay(node.(*Condition)); ok {
+			t.Errorf("expected %s to be left alone", filter)
+		}
+	}
+}
+
+func stamp(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,62 @@
+package filterast
+
+import (
+	"fmt"
+
+	"transaction-filter-backend/schematool"
+)
+
+// SortField is one ordering criterion: a field, or one of its date parts,
+// in ascending or descending order. String fields are ordered by their
+// collation keys, see Fold.
+type SortField struct {
+	Field     schematool.SchemaFieldDefinition
+	Part      string
+	Desc      bool
+	Collation string // the field's collation; empty for other types
+}
+
+// ParseSort reads DevExtreme's sort option, a list whose items are either
+// {"selector": "field", "desc": true} objects or bare field names, resolving
+// each field like Parse does. A nil sort yields no criteria. Problems are
+// reported as ValidationErrors addressed by the item's index.
+func ParseSort(sort interface{}, fields map[string]schematool.SchemaFieldDefinition) ([]SortField, error) {
+	if sort == nil {
+		return nil, nil
+	}
+	items, ok := sort.([]interface{})
+	if !ok {
+		return nil, ValidationErrors{{Code: CodeInvalidValue, Message: fmt.Sprintf("sort must be an array, got %T", sort)}}
+	}
+	var errs ValidationErrors
+	result := make([]SortField, 0, len(items))
+	for i, item := range items {
+		var selector interface{} = item
+		desc := false
+		if obj, isObj := item.(map[string]interface{}); isObj {
+			selector = obj["selector"]
+			desc, _ = obj["desc"].(bool)
+		}
+		name, isStr := selector.(string)
+		if !isStr || name == "" {
+			errs = append(errs, &ValidationError{Path: indexPath("", i), Code: CodeInvalidValue, Value: item,
+				Message: "sort item must be a field name or an object with a \"selector\""})
+			continue
+		}
+		field, part, found := lookupField(fields, name)
+		if !found {
+			errs = append(errs, &ValidationError{Path: indexPath("", i), Code: CodeUnknownField, Field: name,
+				Message: fmt.Sprintf("sort field '%s' not found in schema", name)})
+			continue
+		}
+		sortField := SortField{Field: field, Part: part, Desc: desc}
+		if part == "" {
+			sortField.Collation = fieldCollation(field.Type, field.Collation)
+		}
+		result = append(result, sortField)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
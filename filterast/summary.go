@@ -0,0 +1,235 @@
+package filterast
+
+import (
+	"fmt"
+	"strings"
+
+	"transaction-filter-backend/schematool"
+)
+
+// Summary types of DevExtreme's totalSummary and groupSummary options.
+const (
+	SummarySum   = "sum"
+	SummaryAvg   = "avg"
+	SummaryMin   = "min"
+	SummaryMax   = "max"
+	SummaryCount = "count"
+)
+
+// summaryTypes lists the key types each summary type accepts. count counts
+// records and takes any field, or none.
+var summaryTypes = map[string][]string{
+	SummarySum: {"int", "float64"},
+	SummaryAvg: {"int", "float64"},
+	SummaryMin: {"int", "float64", "time.Time"},
+	SummaryMax: {"int", "float64", "time.Time"},
+}
+
+// SummaryItem is one summary to compute over a set of records: the Type
+// aggregate of a field, or one of its date parts. Field is the zero value
+// for a count without a selector.
+type SummaryItem struct {
+	Field schematool.SchemaFieldDefinition
+	Part  string
+	Type  string
+}
+
+// ValueType returns the type of the aggregated values: "int" for a date
+// part, otherwise the field's type.
+func (s SummaryItem) ValueType() string {
+	if s.Part != "" {
+		return "int"
+	}
+	return s.Field.Type
+}
+
+// ParseSummary reads DevExtreme's totalSummary or groupSummary option, a
+// list of {"selector", "summaryType"} objects, checking each summary type
+// against the field's type: sum and avg take numbers, min and max numbers
+// and dates. Problems are reported as ValidationErrors addressed by the
+// item's index.
+func ParseSummary(summary interface{}, fields map[string]schematool.SchemaFieldDefinition) ([]SummaryItem, error) {
+	if summary == nil {
+		return nil, nil
+	}
+	items, ok := summary.([]interface{})
+	if !ok {
+		return nil, ValidationErrors{{Code: CodeInvalidValue, Message: fmt.Sprintf("summary must be an array, got %T", summary)}}
+	}
+	var errs ValidationErrors
+	result := make([]SummaryItem, 0, len(items))
+	for i, item := range items {
+		fail := func(code, field, format string, args ...interface{}) {
+			errs = append(errs, &ValidationError{Path: indexPath("", i), Code: code, Field: field, Value: item,
+				Message: fmt.Sprintf(format, args...)})
+		}
+		obj, isObj := item.(map[string]interface{})
+		summaryType, _ := obj["summaryType"].(string)
+		summaryType = strings.ToLower(summaryType)
+		allowed, known := summaryTypes[summaryType]
+		if !isObj || (!known && summaryType != SummaryCount) {
+			fail(CodeInvalidValue, "", "summary item must be an object with a \"summaryType\" of sum, avg, min, max or count")
+			continue
+		}
+		summaryItem := SummaryItem{Type: summaryType}
+		name, _ := obj["selector"].(string)
+		if name == "" {
+			if summaryType != SummaryCount {
+				fail(CodeInvalidValue, "", "summary type '%s' requires a \"selector\"", summaryType)
+			} else {
+				result = append(result, summaryItem)
+			}
+			continue
+		}
+		field, part, found := lookupField(fields, name)
+		if !found {
+			fail(CodeUnknownField, name, "summary field '%s' not found in schema", name)
+			continue
+		}
+		summaryItem.Field, summaryItem.Part = field, part
+		if known && !containsString(allowed, summaryItem.ValueType()) {
+			fail(CodeInvalidValue, name, "summary type '%s' is not supported for field type %s of field %s", summaryType, summaryItem.ValueType(), name)
+			continue
+		}
+		result = append(result, summaryItem)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Aggregate is the running state of one summary over a set of records.
+// Aggregates of disjoint sets merge into the aggregate of their union, which
+// is how group summaries roll up from the last group level.
+type Aggregate struct {
+	Type     string
+	Count    int         // values added, or records for a count
+	Sum      interface{} // int or float64; nil before the first value
+	Min, Max interface{}
+}
+
+// Add adds one record's value; nulls are skipped, except by count.
+func (a *Aggregate) Add(v interface{}) {
+	if a.Type == SummaryCount {
+		a.Count++
+		return
+	}
+	if v == nil {
+		return
+	}
+	a.Merge(Aggregate{Count: 1, Sum: v, Min: v, Max: v})
+}
+
+// Merge adds the values of another aggregate of the same summary.
+func (a *Aggregate) Merge(b Aggregate) {
+	a.Count += b.Count
+	if a.Type == SummarySum || a.Type == SummaryAvg {
+		a.Sum = addNumbers(a.Sum, b.Sum)
+	}
+	if b.Min != nil && (a.Min == nil || compareAggregated(b.Min, a.Min) < 0) {
+		a.Min = b.Min
+	}
+	if b.Max != nil && (a.Max == nil || compareAggregated(b.Max, a.Max) > 0) {
+		a.Max = b.Max
+	}
+}
+
+// Value returns the summary's result: 0 for the sum of no values, nil for
+// their average, minimum or maximum.
+func (a Aggregate) Value() interface{} {
+	switch a.Type {
+	case SummarySum:
+		if a.Sum == nil {
+			return 0
+		}
+		return a.Sum
+	case SummaryAvg:
+		if a.Count == 0 {
+			return nil
+		}
+		sum, _ := a.Sum.(float64)
+		if i, isInt := a.Sum.(int); isInt {
+			sum = float64(i)
+		}
+		return sum / float64(a.Count)
+	case SummaryMin:
+		return a.Min
+	case SummaryMax:
+		return a.Max
+	}
+	return a.Count
+}
+
+// addNumbers adds two ints, or two numbers as float64 when one is not an
+// int. A nil operand counts as nothing.
+func addNumbers(a, b interface{}) interface{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	ai, aInt := a.(int)
+	bi, bInt := b.(int)
+	if aInt && bInt {
+		return ai + bi
+	}
+	return toFloat(a) + toFloat(b)
+}
+
+// compareAggregated is CompareValues, comparing an int and a float64
+// numerically.
+func compareAggregated(a, b interface{}) int {
+	_, aInt := a.(int)
+	_, bInt := b.(int)
+	if aInt != bInt {
+		return compareOrdered(toFloat(a), toFloat(b))
+	}
+	return CompareValues(a, b)
+}
+
+func toFloat(v interface{}) float64 {
+	if i, ok := v.(int); ok {
+		return float64(i)
+	}
+	f, _ := v.(float64)
+	return f
+}
+
+// NewAggregates returns empty aggregates for the given summaries.
+func NewAggregates(summaries []SummaryItem) []Aggregate {
+	aggregates := make([]Aggregate, len(summaries))
+	for i, s := range summaries {
+		aggregates[i].Type = s.Type
+	}
+	return aggregates
+}
+
+// SummaryValues merges the aggregates of counts, built for summaries, and
+// returns the summaries' results over all of them.
+func SummaryValues(summaries []SummaryItem, counts []GroupCount) []interface{} {
+	return summaryValues(NewAggregates(summaries), counts)
+}
+
+func summaryValues(aggregates []Aggregate, counts []GroupCount) []interface{} {
+	for _, count := range counts {
+		for i := range aggregates {
+			aggregates[i].Merge(count.Aggregates[i])
+		}
+	}
+	values := make([]interface{}, len(aggregates))
+	for i, a := range aggregates {
+		values[i] = a.Value()
+	}
+	return values
+}
@@ -0,0 +1,115 @@
+package filterast
+
+import (
+	"regexp"
+	"time"
+)
+
+// dateOnlyPattern matches the "yyyy-MM-dd" values DevExtreme sends for
+// fields with dataType "date".
+var dateOnlyPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// isDateOnly reports whether raw is a date without a time of day. Such values
+// stand for the whole day, from midnight to the next midnight in the
+// configured timezone.
+func isDateOnly(raw interface{}) bool {
+	s, ok := raw.(string)
+	return ok && dateOnlyPattern.MatchString(s)
+}
+
+// ExpandWholeDay rewrites a condition on a time.Time field whose value was
+// written as a date only into exact-instant comparisons over the half-open
+// day [start, start+1d):
+//
+//	=  d  ->  >= start and < next
+//	<> d  ->  < start or >= next
+//	>  d  ->  >= next
+//	<= d  ->  < next
+//
+// "<" and ">=" already compare against the start of the day and are left
+// alone. The upper bound of "between" and each date-only value of
+// "anyof"/"noneof" are widened the same way. ok is false when cond needs no
+// rewriting; backends then translate cond as is.
+func ExpandWholeDay(cond *Condition) (node Node, ok bool) {
+	if cond.Type() != "time.Time" {
+		return nil, false
+	}
+	switch cond.Operator {
+	case "=", "<>", ">", "<=":
+		if !isDateOnly(cond.Raw) {
+			return nil, false
+		}
+		start := cond.Value.(time.Time)
+		next := start.AddDate(0, 0, 1)
+		switch cond.Operator {
+		case "=":
+			return dayRange(cond, start, next), true
+		case "<>":
+			return outsideDay(cond, start, next), true
+		case ">":
+			return instantCondition(cond, ">=", next), true
+		default:
+			return instantCondition(cond, "<", next), true
+		}
+	case "between":
+		raw, _ := cond.Raw.([]interface{})
+		if !isDateOnly(rawAt(raw, 1)) {
+			return nil, false
+		}
+		return &Group{Op: And, Children: []Node{
+			instantCondition(cond, ">=", cond.Values[0]),
+			instantCondition(cond, "<", cond.Values[1].(time.Time).AddDate(0, 0, 1)),
+		}}, true
+	case "anyof", "noneof":
+		raw, _ := cond.Raw.([]interface{})
+		expand := false
+		for i := range cond.Values {
+			expand = expand || isDateOnly(rawAt(raw, i))
+		}
+		if !expand {
+			return nil, false
+		}
+		children := make([]Node, 0, len(cond.Values))
+		for i, v := range cond.Values {
+			start := v.(time.Time)
+			next := start.AddDate(0, 0, 1)
+			switch {
+			case cond.Operator == "anyof" && isDateOnly(rawAt(raw, i)):
+				children = append(children, dayRange(cond, start, next))
+			case cond.Operator == "anyof":
+				children = append(children, instantCondition(cond, "=", start))
+			case isDateOnly(rawAt(raw, i)):
+				children = append(children, outsideDay(cond, start, next))
+			default:
+				children = append(children, instantCondition(cond, "<>", start))
+			}
+		}
+		if cond.Operator == "anyof" {
+			return newGroup(Or, children), true
+		}
+		return newGroup(And, children), true
+	}
+	return nil, false
+}
+
+func dayRange(cond *Condition, start, next time.Time) Node {
+	return &Group{Op: And, Children: []Node{instantCondition(cond, ">=", start), instantCondition(cond, "<", next)}}
+}
+
+func outsideDay(cond *Condition, start, next time.Time) Node {
+	return &Group{Op: Or, Children: []Node{instantCondition(cond, "<", start), instantCondition(cond, ">=", next)}}
+}
+
+func instantCondition(cond *Condition, op string, v interface{}) *Condition {
+	return &Condition{Field: cond.Field, Operator: op, Value: v, Raw: v}
+}
+
+// effectiveBound returns the exact-instant bound a range condition stands
+// for, so that date-only and timestamp bounds on one field can be compared:
+// "> d" is ">= next day" and "<= d" is "< next day" when d is date-only.
+func effectiveBound(b rangeBound, lower bool) (value interface{}, inclusive bool) {
+	if t, ok := b.value.(time.Time); ok && isDateOnly(b.raw) && b.inclusive != lower {
+		return t.AddDate(0, 0, 1), lower
+	}
+	return b.value, b.inclusive
+}
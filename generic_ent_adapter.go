@@ -4,12 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
-	"strconv"
 	"strings"
 	"time" // Needed for timeOperatorHandlers
 
 	"transaction-filter-backend/dynamictablefilter"
+	"transaction-filter-backend/filterast"
 	"transaction-filter-backend/schematool"
 
 	"entgo.io/ent/dialect/sql"
@@ -23,13 +22,28 @@ type boolOpHandler func(col string, val bool) (*sql.Predicate, error)
 type timeOpHandler func(col string, val time.Time) (*sql.Predicate, error)
 
 var (
+	// stringOperators receive the column, wrapped in fold() unless the
+	// condition's collation is exact, and the value folded for it, except
+	// "matches", which sees the text as stored. Substring and pattern operators avoid LIKE, which
+	// ignores ASCII case whatever the collation.
 	stringOperators = map[string]stringOpHandler{
 		"=":           func(c, v string) (*sql.Predicate, error) { return sql.EQ(c, v), nil },
 		"<>":          func(c, v string) (*sql.Predicate, error) { return sql.NEQ(c, v), nil },
-		"contains":    func(c, v string) (*sql.Predicate, error) { return sql.ContainsFold(c, v), nil },
-		"notcontains": func(c, v string) (*sql.Predicate, error) { return sql.Not(sql.ContainsFold(c, v)), nil },
-		"startswith":  func(c, v string) (*sql.Predicate, error) { return sql.HasPrefix(c, v), nil },
-		"endswith":    func(c, v string) (*sql.Predicate, error) { return sql.HasSuffix(c, v), nil },
+		"contains":    func(c, v string) (*sql.Predicate, error) { return instrPredicate(c, v, " > 0"), nil },
+		"notcontains": func(c, v string) (*sql.Predicate, error) { return sql.Not(instrPredicate(c, v, " > 0")), nil },
+		"startswith":  func(c, v string) (*sql.Predicate, error) { return instrPredicate(c, v, " = 1"), nil },
+		"endswith": func(c, v string) (*sql.Predicate, error) {
+			return sql.P(func(b *sql.Builder) {
+				b.WriteString("substr(").Ident(c).WriteString(", length(").Ident(c).WriteString(") - length(").Arg(v).WriteString(") + 1) = ").Arg(v)
+			}), nil
+		},
+		"like": func(c, v string) (*sql.Predicate, error) {
+			return sql.P(func(b *sql.Builder) { b.Ident(c).WriteString(" REGEXP ").Arg(filterast.LikePattern(v)) }), nil
+		},
+		"matches": func(c, v string) (*sql.Predicate, error) {
+			// REGEXP calls the regexp() function registered in sqlite_functions.go.
+			return sql.P(func(b *sql.Builder) { b.Ident(c).WriteString(" REGEXP ").Arg(v) }), nil
+		},
 	}
 	intOperators = map[string]intOpHandler{
 		"=":  func(c string, v int) (*sql.Predicate, error) { return sql.EQ(c, v), nil },
@@ -59,8 +73,91 @@ var (
 		"<":  func(c string, v time.Time) (*sql.Predicate, error) { return sql.LT(c, v), nil },
 		"<=": func(c string, v time.Time) (*sql.Predicate, error) { return sql.LTE(c, v), nil },
 	}
+	// columnOperators compare two columns of the same row, for conditions
+	// whose value is a field reference.
+	columnOperators = map[string]func(col1, col2 string) *sql.Predicate{
+		"=":  sql.ColumnsEQ,
+		"<>": sql.ColumnsNEQ,
+		">":  sql.ColumnsGT,
+		">=": sql.ColumnsGTE,
+		"<":  sql.ColumnsLT,
+		"<=": sql.ColumnsLTE,
+	}
 )
 
+// datePartFormats maps date parts to the SQLite strftime format extracting
+// them; Quarter is derived from the month.
+var datePartFormats = map[string]string{
+	filterast.PartYear:      "%Y",
+	filterast.PartQuarter:   "%m",
+	filterast.PartMonth:     "%m",
+	filterast.PartDay:       "%d",
+	filterast.PartDayOfWeek: "%w",
+	filterast.PartHour:      "%H",
+	filterast.PartMinute:    "%M",
+	filterast.PartSecond:    "%S",
+}
+
+// datePartExpression returns an integer SQL expression extracting part from
+// the time column, e.g. CAST(strftime('%m', `date`) AS INTEGER). strftime
+// works in UTC, so outside UTC the time is first shifted by its offset in
+// filterast.Timezone(), which tz_offset (sqlite_functions.go) computes per
+// value to follow daylight saving time, as filterast.DatePart does.
+func datePartExpression(column, part string) string {
+	modifier := ""
+	if filterast.Timezone() != time.UTC {
+		modifier = fmt.Sprintf(", tz_offset(`%s`) || ' seconds'", column)
+	}
+	expr := fmt.Sprintf("CAST(strftime('%s', `%s`%s) AS INTEGER)", datePartFormats[part], column, modifier)
+	if part == filterast.PartQuarter {
+		expr = fmt.Sprintf("((%s + 2) / 3)", expr)
+	}
+	return expr
+}
+
+// instrPredicate compares the position of v in the column, as returned by
+// SQLite's instr(), e.g. " > 0" for "contains".
+func instrPredicate(column, v, comparison string) *sql.Predicate {
+	return sql.P(func(b *sql.Builder) {
+		b.WriteString("instr(").Ident(column).Comma().Arg(v).WriteString(")" + comparison)
+	})
+}
+
+// foldExpression wraps a string column or expression in a call to the fold
+// function registered in sqlite_functions.go, which computes the key text is
+// compared under for collation (see filterast.Fold). Exact comparisons, and
+// columns of other types, use the column as is.
+func foldExpression(column, collation string) string {
+	if collation == "" || collation == filterast.CollationExact {
+		return column
+	}
+	if !strings.HasPrefix(column, "(") {
+		column = "`" + column + "`"
+	}
+	// collation is one of the filterast constants, safe to write inline.
+	return fmt.Sprintf("fold(%s, '%s')", column, collation)
+}
+
+// foldValues folds string values for collation; other values are returned
+// as is.
+func foldValues(values []interface{}, collation string) []interface{} {
+	if collation == "" {
+		return values
+	}
+	folded := make([]interface{}, len(values))
+	for i, v := range values {
+		folded[i] = filterast.Fold(v.(string), collation)
+	}
+	return folded
+}
+
+// writeFuzzyDistance writes a call to the fuzzy_distance function
+// registered in sqlite_functions.go, which compares column and search under
+// collation; it is NULL for a NULL column.
+func writeFuzzyDistance(b *sql.Builder, column, search, collation string) {
+	b.WriteString("fuzzy_distance(").Ident(column).Comma().Arg(search).Comma().Arg(collation).WriteString(")")
+}
+
 type GenericEntAdapter struct {
 	entityName  string
 	tableSchema *dynamictablefilter.TableSchema
@@ -76,124 +173,125 @@ func NewGenericEntAdapter(entityName string) (*GenericEntAdapter, error) {
 	if err := json.Unmarshal(jsonData, &schema); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal schema from %s: %w", schemaPath, err)
 	}
-	schema.FieldMap = make(map[string]schematool.SchemaFieldDefinition)
-	for _, field := range schema.Fields {
-		schema.FieldMap[strings.ToLower(field.Name)] = field
+	if err := schema.Index(); err != nil {
+		return nil, fmt.Errorf("invalid schema in %s: %w", schemaPath, err)
 	}
 	return &GenericEntAdapter{entityName: entityName, tableSchema: &schema}, nil
 }
 
-func (ga *GenericEntAdapter) GetPredicateForField(field string, op string, val interface{}) (PredicateFunc, error) {
-	columnName := strings.ToLower(field)
-	fieldSchema, ok := ga.tableSchema.FieldMap[columnName]
-	if !ok {
-		return nil, fmt.Errorf("field '%s' not found in schema for entity '%s'", field, ga.entityName)
+// Fields returns the entity's field definitions keyed by lower-cased name.
+func (ga *GenericEntAdapter) Fields() map[string]schematool.SchemaFieldDefinition {
+	return ga.tableSchema.FieldMap
+}
+
+// Schema returns the entity's schema definition, fields in declared order.
+func (ga *GenericEntAdapter) Schema() *dynamictablefilter.TableSchema {
+	return ga.tableSchema
+}
+
+// GetColumnExpression returns the column of a stored field, the
+// parenthesized SQL expression of a computed field, or a date part
+// expression. The predicate and order builders write expressions containing
+// parentheses verbatim and quote plain column names.
+func (ga *GenericEntAdapter) GetColumnExpression(field schematool.SchemaFieldDefinition, part string) string {
+	column := strings.ToLower(field.Name)
+	if expr, ok := ga.tableSchema.Computed[column]; ok {
+		return "(" + expr.SQL() + ")"
 	}
+	if part != "" {
+		return datePartExpression(column, part)
+	}
+	return column
+}
 
-	opLower := strings.ToLower(op)
+// GetPredicateForField parses and validates a single raw condition before
+// translating it; filters going through ParseFilterToPredicates are already
+// parsed and use GetPredicateForCondition directly.
+func (ga *GenericEntAdapter) GetPredicateForField(field string, op string, val interface{}) (PredicateFunc, error) {
+	cond, err := filterast.NewCondition(ga.tableSchema.FieldMap, field, op, val)
+	if err != nil {
+		return nil, fmt.Errorf("entity '%s': %w", ga.entityName, err)
+	}
+	return ga.GetPredicateForCondition(cond)
+}
 
-	if opLower == "between" {
-		valueSlice, ok := val.([]interface{})
-		if !ok || len(valueSlice) != 2 {
-			return nil, fmt.Errorf("operator 'between' requires an array of two values, got %T for field %s", val, field)
+// GetPredicateForCondition translates a parsed condition, whose value is
+// already coerced to the field's Go type, into an *sql.Predicate.
+func (ga *GenericEntAdapter) GetPredicateForCondition(cond *filterast.Condition) (PredicateFunc, error) {
+	columnName := ga.GetColumnExpression(cond.Field, cond.Part)
+	collated := foldExpression(columnName, cond.Collation)
+	fieldType := cond.Type()
+	if ref := cond.ValueField; ref != nil {
+		refColumn := foldExpression(ga.GetColumnExpression(ref.Field, ref.Part), cond.Collation)
+		if handler, found := columnOperators[cond.Operator]; found {
+			return handler(collated, refColumn), nil
 		}
+		return nil, fmt.Errorf("unsupported operator '%s' for comparing field %s with field %s", cond.Operator, cond.Field.Name, ref.Field.Name)
+	}
 
-		switch fieldSchema.Type {
-		case "int":
-			log.Printf("DEBUG: 'between' int, valueSlice[0] type: %T, value: %+v", valueSlice[0], valueSlice[0])
-			log.Printf("DEBUG: 'between' int, valueSlice[1] type: %T, value: %+v", valueSlice[1], valueSlice[1])
-			lower, errL := convertToInt(valueSlice[0])
-			if errL != nil {
-				return nil, fmt.Errorf("invalid lower bound for 'between' on int field %s: %w", field, errL)
-			}
-			upper, errU := convertToInt(valueSlice[1])
-			if errU != nil {
-				return nil, fmt.Errorf("invalid upper bound for 'between' on int field %s: %w", field, errU)
-			}
-			return sql.And(sql.GTE(columnName, lower), sql.LTE(columnName, upper)), nil
-		case "float64":
-			log.Printf("DEBUG: 'between' float64, valueSlice[0] type: %T, value: %+v", valueSlice[0], valueSlice[0])
-			log.Printf("DEBUG: 'between' float64, valueSlice[1] type: %T, value: %+v", valueSlice[1], valueSlice[1])
-			lower, errL := convertToFloat64(valueSlice[0])
-			if errL != nil {
-				return nil, fmt.Errorf("invalid lower bound for 'between' on float field %s: %w", field, errL)
-			}
-			upper, errU := convertToFloat64(valueSlice[1])
-			if errU != nil {
-				return nil, fmt.Errorf("invalid upper bound for 'between' on float field %s: %w", field, errU)
-			}
-			return sql.And(sql.GTE(columnName, lower), sql.LTE(columnName, upper)), nil
-		case "time.Time":
-			log.Printf("DEBUG: 'between' time.Time, valueSlice[0] type: %T, value: %+v", valueSlice[0], valueSlice[0])
-			log.Printf("DEBUG: 'between' time.Time, valueSlice[1] type: %T, value: %+v", valueSlice[1], valueSlice[1])
-			lower, errL := convertToTime(valueSlice[0])
-			if errL != nil {
-				return nil, fmt.Errorf("invalid lower bound for 'between' on time field %s: %w", field, errL)
-			}
-			upper, errU := convertToTime(valueSlice[1])
-			if errU != nil {
-				return nil, fmt.Errorf("invalid upper bound for 'between' on time field %s: %w", field, errU)
-			}
-			return sql.And(sql.GTE(columnName, lower), sql.LTE(columnName, upper)), nil
-		default:
-			return nil, fmt.Errorf("'between' operator not supported for field type %s of field %s", fieldSchema.Type, field)
+	// Blank and null checks carry no typed value. For strings DevExtreme
+	// treats the empty string as blank.
+	switch cond.Operator {
+	case "isblank":
+		if filterast.IsStringType(fieldType) {
+			return sql.Or(sql.IsNull(columnName), sql.EQ(columnName, "")), nil
+		}
+		return sql.IsNull(columnName), nil
+	case "isnotblank":
+		if filterast.IsStringType(fieldType) {
+			return sql.And(sql.NotNull(columnName), sql.NEQ(columnName, "")), nil
+		}
+		return sql.NotNull(columnName), nil
+	case "anyof":
+		return sql.In(collated, foldValues(cond.Values, cond.Collation)...), nil
+	case "noneof":
+		return sql.NotIn(collated, foldValues(cond.Values, cond.Collation)...), nil
+	case "between":
+		return sql.And(sql.GTE(columnName, cond.Values[0]), sql.LTE(columnName, cond.Values[1])), nil
+	case "fuzzy":
+		fuzzy := cond.Value.(filterast.FuzzyValue)
+		return sql.P(func(b *sql.Builder) {
+			writeFuzzyDistance(b, columnName, fuzzy.Value, cond.Collation)
+			b.WriteString(" <= ").Arg(fuzzy.Distance)
+		}), nil
+	}
+	if cond.Value == nil {
+		switch cond.Operator {
+		case "=":
+			return sql.IsNull(columnName), nil
+		case "<>":
+			return sql.NotNull(columnName), nil
 		}
 	}
 
-	// Handle other operators
-	switch fieldSchema.Type {
+	switch fieldType {
 	case "string", "text":
-		strVal, ok := val.(string)
-		if !ok {
-			return nil, fmt.Errorf("value for string field %s must be a string", field)
-		}
-		if handler, found := stringOperators[opLower]; found {
-			return handler(columnName, strVal)
+		if handler, found := stringOperators[cond.Operator]; found {
+			if cond.Operator == "matches" {
+				return handler(columnName, cond.Value.(string))
+			}
+			return handler(collated, filterast.Fold(cond.Value.(string), cond.Collation))
 		}
 	case "int":
-		intVal, err := convertToInt(val)
-		if err != nil {
-			return nil, fmt.Errorf("invalid value for int field %s: %w", field, err)
-		}
-		if handler, found := intOperators[opLower]; found {
-			return handler(columnName, intVal)
+		if handler, found := intOperators[cond.Operator]; found {
+			return handler(columnName, cond.Value.(int))
 		}
 	case "float64":
-		floatVal, err := convertToFloat64(val)
-		if err != nil {
-			return nil, fmt.Errorf("invalid value for float field %s: %w", field, err)
-		}
-		if handler, found := floatOperators[opLower]; found {
-			return handler(columnName, floatVal)
+		if handler, found := floatOperators[cond.Operator]; found {
+			return handler(columnName, cond.Value.(float64))
 		}
 	case "bool":
-		boolVal, okConv := val.(bool)
-		if !okConv {
-			if strVal, okStr := val.(string); okStr {
-				parsed, err := strconv.ParseBool(strings.ToLower(strVal))
-				if err != nil {
-					return nil, fmt.Errorf("invalid value for bool field %s: expected bool or 'true'/'false'", field)
-				}
-				boolVal = parsed
-			} else {
-				return nil, fmt.Errorf("value for bool field %s must be a boolean or string 'true'/'false'", field)
-			}
-		}
-		if handler, found := boolOperators[opLower]; found {
-			return handler(columnName, boolVal)
+		if handler, found := boolOperators[cond.Operator]; found {
+			return handler(columnName, cond.Value.(bool))
 		}
 	case "time.Time":
-		timeVal, err := convertToTime(val)
-		if err != nil {
-			return nil, fmt.Errorf("invalid value for time field %s: %w", field, err)
-		}
-		if handler, found := timeOperators[opLower]; found {
-			return handler(columnName, timeVal)
+		if handler, found := timeOperators[cond.Operator]; found {
+			return handler(columnName, cond.Value.(time.Time))
 		}
 	default:
-		return nil, fmt.Errorf("unsupported field type '%s' in generic adapter for field '%s'", fieldSchema.Type, field)
+		return nil, fmt.Errorf("unsupported field type '%s' in generic adapter for field '%s'", fieldType, cond.Field.Name)
 	}
-	return nil, fmt.Errorf("unsupported operator '%s' for field type %s of field %s", op, fieldSchema.Type, field)
+	return nil, fmt.Errorf("unsupported operator '%s' for field type %s of field %s", cond.Operator, fieldType, cond.Field.Name)
 }
 
 func (ga *GenericEntAdapter) GetAndPredicate(predicates ...PredicateFunc) PredicateFunc {
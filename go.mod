@@ -6,6 +6,7 @@ require (
 	entgo.io/ent v0.14.4
 	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/rs/cors v1.11.1
+	golang.org/x/text v0.21.0
 )
 
 require (
@@ -22,5 +23,4 @@ require (
 	github.com/zclconf/go-cty v1.14.4 // indirect
 	github.com/zclconf/go-cty-yaml v1.1.0 // indirect
 	golang.org/x/mod v0.23.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
 )
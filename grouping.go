@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"transaction-filter-backend/filterast"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"github.com/mattn/go-sqlite3"
+)
+
+// groupPage nests counts into groups and pages the top-level ones. When
+// every level is expanded, fetch is asked for the records of the page's
+// groups, which it must return in group order, and they are handed out to
+// the last-level groups. It also returns the number of top-level groups.
+func groupPage(counts []filterast.GroupCount, groups []filterast.GroupField, skip, take int,
+	fetch func(skip, take int) ([]map[string]interface{}, error)) ([]*filterast.GroupItem, int, error) {
+	items := filterast.NestGroups(counts, groups)
+	start := min(skip, len(items))
+	end := len(items)
+	if take > 0 {
+		end = min(start+take, end)
+	}
+	page := items[start:end]
+	if len(page) == 0 || !filterast.GroupsExpanded(groups) {
+		return page, len(items), nil
+	}
+	before, inPage := 0, 0
+	for _, item := range items[:start] {
+		before += item.Count
+	}
+	for _, item := range page {
+		inPage += item.Count
+	}
+	records, err := fetch(before, inPage)
+	if err != nil {
+		return nil, 0, err
+	}
+	filterast.FillGroups(page, records)
+	return page, len(items), nil
+}
+
+// loadEntityGroups answers a grouped load for an ent entity: the groups,
+// their counts and group summaries come from a GROUP BY query, and the
+// records of expanded groups from the entity's query ordered by the group
+// keys, then orderBy.
+func loadEntityGroups(ctx context.Context, entity string, adapter EntityAdapter, pred *sql.Predicate, groups []filterast.GroupField,
+	summaries []filterast.SummaryItem, orderBy func(*sql.Selector), o *loadOptions) ([]*filterast.GroupItem, int, error) {
+	counts, err := countEntityGroups(ctx, entity, adapter, pred, groups, summaries)
+	if err != nil {
+		return nil, 0, err
+	}
+	byGroup := orderByGroups(adapter, groups)
+	return groupPage(counts, groups, o.Skip, o.Take, func(skip, take int) ([]map[string]interface{}, error) {
+		results, err := queryEntities(ctx, entity, adapter, pred, func(s *sql.Selector) {
+			byGroup(s)
+			orderBy(s)
+		}, skip, take)
+		if err != nil {
+			return nil, err
+		}
+		return recordMaps(results)
+	})
+}
+
+// summaryFunctions maps summary types to the SQL aggregate computing them
+// for one group; an average is the SUM over the COUNT selected with it.
+var summaryFunctions = map[string]string{
+	filterast.SummarySum: "SUM",
+	filterast.SummaryAvg: "SUM",
+	filterast.SummaryMin: "MIN",
+	filterast.SummaryMax: "MAX",
+}
+
+// countEntityGroups counts the entity's rows matching pred, which may be
+// nil, for each combination of group keys, in group order, aggregating the
+// given summaries over them. Without groups there is a single combination,
+// also when no row matches.
+func countEntityGroups(ctx context.Context, entity string, adapter EntityAdapter, pred *sql.Predicate,
+	groups []filterast.GroupField, summaries []filterast.SummaryItem) ([]filterast.GroupCount, error) {
+	table, ok := entityTables[strings.ToLower(entity)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported entity type: %s", entity)
+	}
+	keys := make([]string, len(groups))
+	for i, g := range groups {
+		keys[i] = groupKeyExpression(adapter, g)
+	}
+	columns := append(append([]string{}, keys...), sql.Count("*"))
+	for _, s := range summaries {
+		if fn, ok := summaryFunctions[s.Type]; ok {
+			column := sqlColumn(adapter.GetColumnExpression(s.Field, s.Part))
+			columns = append(columns, fmt.Sprintf("%s(%s)", fn, column), fmt.Sprintf("COUNT(%s)", column))
+		}
+	}
+	selector := sql.Dialect(dialect.SQLite).Select(columns...).From(sql.Table(table)).GroupBy(keys...)
+	if pred != nil {
+		selector.Where(pred)
+	}
+	orderByGroups(adapter, groups)(selector)
+	query, args := selector.Query()
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("grouping rows: %w", err)
+	}
+	defer rows.Close()
+	var counts []filterast.GroupCount
+	for rows.Next() {
+		count := filterast.GroupCount{Keys: make([]interface{}, len(groups)), Aggregates: filterast.NewAggregates(summaries)}
+		dest := make([]interface{}, 0, len(columns))
+		for i := range count.Keys {
+			dest = append(dest, &count.Keys[i])
+		}
+		dest = append(dest, &count.Count)
+		values := make([]interface{}, len(summaries))
+		for i, s := range summaries {
+			if _, ok := summaryFunctions[s.Type]; ok {
+				dest = append(dest, &values[i], &count.Aggregates[i].Count)
+			}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("grouping rows: %w", err)
+		}
+		for i, g := range groups {
+			count.Keys[i] = sqlValue(count.Keys[i], g.Type())
+		}
+		for i, s := range summaries {
+			a := &count.Aggregates[i]
+			switch v := sqlValue(values[i], s.ValueType()); s.Type {
+			case filterast.SummarySum, filterast.SummaryAvg:
+				a.Sum = v
+			case filterast.SummaryMin:
+				a.Min = v
+			case filterast.SummaryMax:
+				a.Max = v
+			default:
+				a.Count = count.Count
+			}
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("grouping rows: %w", err)
+	}
+	return counts, nil
+}
+
+// groupKeyExpression returns the SQL for a group level's key: the field's
+// column expression, wrapped in the bucket function registered in
+// sqlite_functions.go for a numeric groupInterval.
+func groupKeyExpression(adapter EntityAdapter, g filterast.GroupField) string {
+	column := adapter.GetColumnExpression(g.Field, g.Part)
+	if g.Interval == 0 {
+		return column
+	}
+	return fmt.Sprintf("bucket(%s, %s)", sqlColumn(column), strconv.FormatFloat(g.Interval, 'g', -1, 64))
+}
+
+// sqlColumn quotes a plain column name for use inside an expression written
+// verbatim; column expressions are returned as is.
+func sqlColumn(column string) string {
+	if strings.Contains(column, "(") {
+		return column
+	}
+	return "`" + column + "`"
+}
+
+// orderByGroups returns a selector modifier ordering rows by their group
+// keys, strings by their collation key first and then as stored, the order
+// dynamictablefilter.GroupDynamicData uses.
+func orderByGroups(adapter EntityAdapter, groups []filterast.GroupField) func(*sql.Selector) {
+	return func(s *sql.Selector) {
+		for _, g := range groups {
+			key := groupKeyExpression(adapter, g)
+			columns := []string{key}
+			if folded := foldExpression(key, g.Collation); folded != key {
+				columns = []string{folded, key}
+			}
+			for _, column := range columns {
+				if g.Desc {
+					s.OrderBy(sql.Desc(column))
+				} else {
+					s.OrderBy(sql.Asc(column))
+				}
+			}
+		}
+	}
+}
+
+// sqlValue converts a group key or aggregate of valueType scanned from
+// SQLite to the Go type the dynamic table engine uses for it. Aggregates of
+// time columns come back as text.
+func sqlValue(v interface{}, valueType string) interface{} {
+	switch value := v.(type) {
+	case []byte:
+		return sqlValue(string(value), valueType)
+	case string:
+		if valueType == "time.Time" {
+			for _, layout := range sqlite3.SQLiteTimestampFormats {
+				if t, err := time.Parse(layout, value); err == nil {
+					return t.UTC()
+				}
+			}
+		}
+		return value
+	case int64:
+		if valueType == "bool" {
+			return value != 0
+		}
+		return int(value)
+	case time.Time:
+		return value.UTC()
+	}
+	return v
+}
+
+// recordMaps converts query results to the JSON form of their records.
+func recordMaps(results interface{}) ([]map[string]interface{}, error) {
+	if records, ok := results.([]map[string]interface{}); ok {
+		return records, nil
+	}
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		return nil, err
+	}
+	var records []map[string]interface{}
+	if err := json.Unmarshal(encoded, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"transaction-filter-backend/filterast"
+	"transaction-filter-backend/schematool"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+)
+
+// loadOptions are the DevExtreme CustomStore load options accepted by
+// /filter and /dynamic-tables/{table}/filter, along with this backend's own
+// extensions.
+type loadOptions struct {
+	Filter            interface{} `json:"filter"`
+	Sort              interface{} `json:"sort"`
+	Skip              int         `json:"skip"`
+	Take              int         `json:"take"` // 0 means no limit
+	RequireTotalCount bool        `json:"requireTotalCount"`
+	Group             interface{} `json:"group"`
+	RequireGroupCount bool        `json:"requireGroupCount"`
+	TotalSummary      interface{} `json:"totalSummary"`
+	GroupSummary      interface{} `json:"groupSummary"`
+	// RankByDistance orders results by their distance to the filter's
+	// "fuzzy" conditions, closest first, before any sort criteria.
+	RankByDistance bool `json:"rankByDistance"`
+	// Collation, when set, replaces the collation of every string field
+	// in the filter, sort and group options, see filterast.SetCollation.
+	Collation string `json:"collation"`
+}
+
+// loadResult is the response of both filter endpoints, the shape DevExtreme
+// CustomStore expects from its load function. TotalCount is the number of records matching the
+// filter before paging, or -1 when it was not required. GroupCount is the
+// number of top-level groups before paging, sent for grouped loads only.
+// Summary holds the results of the total summaries over every record
+// matching the filter.
+type loadResult struct {
+	Data       interface{}   `json:"data"`
+	TotalCount int           `json:"totalCount"`
+	GroupCount *int          `json:"groupCount,omitempty"`
+	Summary    []interface{} `json:"summary,omitempty"`
+}
+
+// validate reports load options the endpoints cannot honor.
+func (o *loadOptions) validate() error {
+	if o.Skip < 0 || o.Take < 0 {
+		return fmt.Errorf("skip and take must not be negative")
+	}
+	return nil
+}
+
+// grouped reports whether the request asks for groups rather than records.
+// With groups, skip and take page the top-level groups.
+func (o *loadOptions) grouped() bool {
+	return o.Group != nil
+}
+
+// parseSummaries parses the group and total summaries against fields.
+func (o *loadOptions) parseSummaries(fields map[string]schematool.SchemaFieldDefinition) (groupSummary, totalSummary []filterast.SummaryItem, err error) {
+	if groupSummary, err = filterast.ParseSummary(o.GroupSummary, fields); err != nil {
+		return nil, nil, err
+	}
+	if totalSummary, err = filterast.ParseSummary(o.TotalSummary, fields); err != nil {
+		return nil, nil, err
+	}
+	return groupSummary, totalSummary, nil
+}
+
+// pager is implemented by the generated ent query builders.
+type pager[Q any] interface {
+	Offset(int) Q
+	Limit(int) Q
+}
+
+// pageQuery applies skip and take to an ent query, which renders them as
+// OFFSET and LIMIT. A take of 0 means no limit.
+func pageQuery[Q pager[Q]](query Q, skip, take int) Q {
+	if skip > 0 {
+		query = query.Offset(skip)
+	}
+	if take > 0 {
+		query = query.Limit(take)
+	}
+	return query
+}
+
+// pageRecords applies skip and take to in-memory records.
+func pageRecords(records []map[string]interface{}, skip, take int) []map[string]interface{} {
+	if skip >= len(records) {
+		return []map[string]interface{}{}
+	}
+	records = records[skip:]
+	if take > 0 && take < len(records) {
+		records = records[:take]
+	}
+	return records
+}
+
+// countEntityRows counts the rows of an entity's table matching pred, which
+// may be nil.
+func countEntityRows(ctx context.Context, entity string, pred *sql.Predicate) (int, error) {
+	table, ok := entityTables[strings.ToLower(entity)]
+	if !ok {
+		return 0, fmt.Errorf("unsupported entity type: %s", entity)
+	}
+	selector := sql.Dialect(dialect.SQLite).Select(sql.Count("*")).From(sql.Table(table))
+	if pred != nil {
+		selector.Where(pred)
+	}
+	query, args := selector.Query()
+	var count int
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("counting rows: %w", err)
+	}
+	return count, nil
+}
+
+// writeLoadResult writes result as the JSON response. Counts that were not
+// required are sent as -1.
+func writeLoadResult(w http.ResponseWriter, o *loadOptions, result loadResult) {
+	w.Header().Set("Content-Type", "application/json")
+	if !o.RequireTotalCount {
+		result.TotalCount = -1
+	}
+	if !o.grouped() {
+		result.GroupCount = nil
+	} else if !o.RequireGroupCount || result.GroupCount == nil {
+		notRequired := -1
+		result.GroupCount = &notRequired
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	stdsql "database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,12 +13,15 @@ import (
 	"time"
 	"transaction-filter-backend/dynamictablefilter"
 	"transaction-filter-backend/ent"
+	"transaction-filter-backend/filterast"
 	"transaction-filter-backend/schematool"
 
-	_ "transaction-filter-backend/ent/test1schema"
-	_ "transaction-filter-backend/ent/test2schema"
-	_ "transaction-filter-backend/ent/test3schema"
+	"transaction-filter-backend/ent/test1schema"
+	"transaction-filter-backend/ent/test2schema"
+	"transaction-filter-backend/ent/test3schema"
+	"transaction-filter-backend/ent/transaction"
 
+	"entgo.io/ent/dialect"
 	"entgo.io/ent/dialect/sql" // Keep this for sql.Selector and potentially sql.P if needed elsewhere
 
 	_ "github.com/mattn/go-sqlite3"
@@ -25,13 +30,55 @@ import (
 
 var client *ent.Client
 
+// db is the database behind client, for statements ent does not model such
+// as EXPLAIN QUERY PLAN.
+var db *stdsql.DB
+
+// entityTables maps each registered entity to its SQL table.
+var entityTables = map[string]string{
+	"transaction": transaction.Table,
+	"test1schema": test1schema.Table,
+	"test2schema": test2schema.Table,
+	"test3schema": test3schema.Table,
+}
+
+// openClient opens an ent client on SQLite, through the driver with the
+// filter functions from sqlite_functions.go, and returns the underlying
+// database handle alongside it.
+func openClient(dataSourceName string) (*ent.Client, *stdsql.DB, error) {
+	registerSQLiteDriver()
+	sqlDB, err := stdsql.Open(sqliteDriverName, dataSourceName)
+	if err != nil {
+		return nil, nil, err
+	}
+	drv := sql.OpenDB(dialect.SQLite, sqlDB)
+	return ent.NewClient(ent.Driver(drv)), sqlDB, nil
+}
+
 func init() {
 	var err error
-	client, err = ent.Open("sqlite3", "file:ent?mode=memory&cache=shared&_fk=1")
+	client, db, err = openClient("file:ent?mode=memory&cache=shared&_fk=1")
 	if err != nil {
 		log.Fatalf("failed opening connection to sqlite: %v", err)
 	}
 
+	// Relative date tokens in filters ("today", "startOfMonth", ...), whole
+	// days and date parts are computed in this timezone; UTC when unset.
+	if tz := os.Getenv("FILTER_TIMEZONE"); tz != "" {
+		loc, errTz := time.LoadLocation(tz)
+		if errTz != nil {
+			log.Printf("Warning: Invalid FILTER_TIMEZONE %q: %v. Using UTC.", tz, errTz)
+		} else {
+			filterast.SetTimezone(loc)
+		}
+	}
+
+	// A condition compares two fields when its value is an object with this
+	// key, e.g. {"field": "cost_price"}; "field" when unset.
+	if key := os.Getenv("FILTER_FIELD_REF_KEY"); key != "" {
+		filterast.SetFieldRefKey(key)
+	}
+
 	entitiesToRegister := []string{"transaction", "test1schema", "test2schema", "test3schema"}
 	for _, entityName := range entitiesToRegister {
 		adapter, errAdapter := NewGenericEntAdapter(entityName)
@@ -46,6 +93,7 @@ func init() {
 
 type Transaction struct {
 	ID       int       `json:"id"`
+	Version  int       `json:"version"`
 	Date     time.Time `json:"date"`
 	Amount   float64   `json:"amount"`
 	Name     string    `json:"name"`
@@ -134,8 +182,8 @@ func filterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var requestBody struct {
-		Entity string      `json:"entity"`
-		Filter interface{} `json:"filter"`
+		Entity string `json:"entity"`
+		loadOptions
 	}
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&requestBody); err != nil {
@@ -143,6 +191,10 @@ func filterHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	if err := requestBody.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if requestBody.Entity == "" {
 		log.Printf("Backend: Missing 'entity' field in request body")
 		http.Error(w, "Missing 'entity' field in request body", http.StatusBadRequest)
@@ -155,72 +207,516 @@ func filterHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, fmt.Sprintf("No adapter for entity '%s'", requestBody.Entity), http.StatusBadRequest)
 		return
 	}
-	finalPredicateAsSqlP, err := ParseFilterToPredicates(adapter, requestBody.Filter) // This now returns *sql.Predicate
+	root, err := filterast.Parse(requestBody.Filter, adapter.Fields())
 	if err != nil {
 		log.Printf("Backend: Error parsing filter for entity '%s': %v", requestBody.Entity, err)
-		http.Error(w, fmt.Sprintf("Error parsing filter: %v", err), http.StatusInternalServerError)
+		writeFilterError(w, err)
+		return
+	}
+	if err := filterast.SetCollation(root, requestBody.Collation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	finalPredicateAsSqlP, err := BuildPredicate(adapter, root) // This now returns *sql.Predicate
+	if err != nil {
+		log.Printf("Backend: Error parsing filter for entity '%s': %v", requestBody.Entity, err)
+		writeFilterError(w, err)
+		return
+	}
+	sorts, err := filterast.ParseSort(requestBody.Sort, adapter.Fields())
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+
+	groups, err := filterast.ParseGroup(requestBody.Group, adapter.Fields())
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	filterast.SetSortCollation(sorts, requestBody.Collation)
+	filterast.SetGroupCollation(groups, requestBody.Collation)
+	groupSummary, totalSummary, err := requestBody.parseSummaries(adapter.Fields())
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	if _, ok := entityTables[strings.ToLower(requestBody.Entity)]; !ok {
+		log.Printf("Backend: Unsupported entity type for filtering: %s", requestBody.Entity)
+		http.Error(w, fmt.Sprintf("Unsupported entity type: %s", requestBody.Entity), http.StatusBadRequest)
 		return
 	}
 
-	var results interface{}
-	var queryError error
 	ctx := context.Background()
+	orderBy := orderBySorts(adapter, sorts)
+	if requestBody.RankByDistance {
+		rank, sortOrder := orderByFuzzyRank(adapter, filterast.FuzzyConditions(root)), orderBy
+		orderBy = func(s *sql.Selector) {
+			rank(s)
+			sortOrder(s)
+		}
+	}
+	// Pages must not overlap, so rows that tie on every sort criterion are
+	// kept in id order.
+	sortOrder := orderBy
+	orderBy = func(s *sql.Selector) {
+		sortOrder(s)
+		s.OrderBy(sql.Asc(s.C("id")))
+	}
 
-	// Helper function to apply the predicate
+	var result loadResult
+	var queryError error
+	if len(groups) > 0 {
+		var groupCount int
+		result.Data, groupCount, queryError = loadEntityGroups(ctx, requestBody.Entity, adapter, finalPredicateAsSqlP, groups, groupSummary, orderBy, &requestBody.loadOptions)
+		result.GroupCount = &groupCount
+	} else {
+		result.Data, queryError = queryEntities(ctx, requestBody.Entity, adapter, finalPredicateAsSqlP, orderBy, requestBody.Skip, requestBody.Take)
+	}
+	if queryError == nil && requestBody.RequireTotalCount {
+		result.TotalCount, queryError = countEntityRows(ctx, requestBody.Entity, finalPredicateAsSqlP)
+	}
+	if queryError == nil && len(totalSummary) > 0 {
+		var totals []filterast.GroupCount
+		totals, queryError = countEntityGroups(ctx, requestBody.Entity, adapter, finalPredicateAsSqlP, nil, totalSummary)
+		result.Summary = filterast.SummaryValues(totalSummary, totals)
+	}
+	if queryError != nil {
+		log.Printf("Backend: Error executing query for entity '%s': %v", requestBody.Entity, queryError)
+		http.Error(w, fmt.Sprintf("Error executing query: %v", queryError), http.StatusInternalServerError)
+		return
+	}
+	writeLoadResult(w, &requestBody.loadOptions, result)
+}
+
+// queryEntities returns the entity's records matching pred, which may be
+// nil, in the order set by orderBy, skipping skip records and returning at
+// most take of them when take is positive. Computed fields are included.
+func queryEntities(ctx context.Context, entity string, adapter EntityAdapter, pred *sql.Predicate, orderBy func(*sql.Selector), skip, take int) (interface{}, error) {
 	applyPred := func(s *sql.Selector) {
-		if finalPredicateAsSqlP != nil {
-			s.Where(finalPredicateAsSqlP)
+		if pred != nil {
+			s.Where(pred)
 		}
 	}
-
-	switch strings.ToLower(requestBody.Entity) {
+	var results interface{}
+	var err error
+	switch strings.ToLower(entity) {
 	case "transaction":
-		query := client.Transaction.Query()
-		if finalPredicateAsSqlP != nil {
-			query = query.Where(applyPred)
+		dbResults, errDb := pageQuery(client.Transaction.Query().Order(orderBy).Where(applyPred), skip, take).All(ctx)
+		if errDb != nil {
+			return nil, errDb
 		}
-		dbResults, errDb := query.All(ctx)
-		queryError = errDb
-		if errDb == nil {
-			dtoResults := make([]Transaction, len(dbResults))
-			for i, trx := range dbResults {
-				dtoResults[i] = Transaction{
-					ID: trx.ID, Date: trx.Date, Amount: trx.Amount, Name: trx.Name,
-					Location: trx.Location, Category: trx.Category, Type: trx.Type,
-				}
+		dtoResults := make([]Transaction, len(dbResults))
+		for i, trx := range dbResults {
+			dtoResults[i] = Transaction{
+				ID: trx.ID, Version: trx.Version, Date: trx.Date, Amount: trx.Amount, Name: trx.Name,
+				Location: trx.Location, Category: trx.Category, Type: trx.Type,
 			}
-			results = dtoResults
 		}
+		results = dtoResults
 	case "test1schema":
-		query := client.Test1Schema.Query()
-		if finalPredicateAsSqlP != nil {
-			query = query.Where(applyPred)
-		}
-		results, queryError = query.All(ctx)
+		results, err = pageQuery(client.Test1Schema.Query().Order(orderBy).Where(applyPred), skip, take).All(ctx)
 	case "test2schema":
-		query := client.Test2Schema.Query()
-		if finalPredicateAsSqlP != nil {
-			query = query.Where(applyPred)
-		}
-		results, queryError = query.All(ctx)
+		results, err = pageQuery(client.Test2Schema.Query().Order(orderBy).Where(applyPred), skip, take).All(ctx)
 	case "test3schema":
-		query := client.Test3Schema.Query()
-		if finalPredicateAsSqlP != nil {
-			query = query.Where(applyPred)
+		results, err = pageQuery(client.Test3Schema.Query().Order(orderBy).Where(applyPred), skip, take).All(ctx)
+	default:
+		return nil, fmt.Errorf("unsupported entity type: %s", entity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return withComputedFields(ctx, entity, adapter, results)
+}
+
+// orderBySorts returns a selector modifier ordering rows by the given
+// criteria, using the adapter's column expressions so computed fields and
+// date parts sort like they filter.
+func orderBySorts(adapter EntityAdapter, sorts []filterast.SortField) func(*sql.Selector) {
+	return func(s *sql.Selector) {
+		for _, sort := range sorts {
+			column := foldExpression(adapter.GetColumnExpression(sort.Field, sort.Part), sort.Collation)
+			if sort.Desc {
+				s.OrderBy(sql.Desc(column))
+			} else {
+				s.OrderBy(sql.Asc(column))
+			}
+		}
+	}
+}
+
+// orderByFuzzyRank returns a selector modifier ordering rows by their
+// smallest distance to the given "fuzzy" conditions, closest first. Like in
+// dynamic tables, a NULL column ranks as filterast.NoFuzzyMatch.
+func orderByFuzzyRank(adapter EntityAdapter, conds []*filterast.Condition) func(*sql.Selector) {
+	return func(s *sql.Selector) {
+		if len(conds) == 0 {
+			return
+		}
+		// OrderExprFunc would drop the search arguments, ExprFunc keeps them.
+		s.OrderExpr(sql.ExprFunc(func(b *sql.Builder) {
+			if len(conds) > 1 {
+				b.WriteString("min(") // the scalar min() takes two or more arguments
+			}
+			for i, cond := range conds {
+				if i > 0 {
+					b.Comma()
+				}
+				b.WriteString("coalesce(")
+				column := adapter.GetColumnExpression(cond.Field, "")
+				writeFuzzyDistance(b, column, cond.Value.(filterast.FuzzyValue).Value, cond.Collation)
+				b.Comma().Arg(filterast.NoFuzzyMatch).WriteString(")")
+			}
+			if len(conds) > 1 {
+				b.WriteString(")")
+			}
+		}))
+	}
+}
+
+// withComputedFields adds the values of the entity's computed fields to
+// query results. They are computed by SQLite from the same expressions used
+// for filtering, in one query over the returned ids, and merged into the
+// JSON form of each result. Results are returned unchanged when the entity
+// has no computed fields.
+func withComputedFields(ctx context.Context, entity string, adapter EntityAdapter, results interface{}) (interface{}, error) {
+	var computed []schematool.SchemaFieldDefinition
+	for _, field := range adapter.Fields() {
+		if field.Expression != "" {
+			computed = append(computed, field)
+		}
+	}
+	table, ok := entityTables[strings.ToLower(entity)]
+	if len(computed) == 0 || !ok {
+		return results, nil
+	}
+	rows, err := recordMaps(results)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return rows, nil
+	}
+	ids := make([]interface{}, len(rows))
+	for i, row := range rows {
+		ids[i] = row["id"]
+	}
+
+	selector := sql.Dialect(dialect.SQLite).Select("id").From(sql.Table(table)).Where(sql.In("id", ids...))
+	for _, field := range computed {
+		selector.AppendSelectExprAs(sql.Raw(adapter.GetColumnExpression(field, "")), field.Name)
+	}
+	query, args := selector.Query()
+	sqlRows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("computing fields: %w", err)
+	}
+	defer sqlRows.Close()
+	values := make(map[float64][]interface{}, len(rows))
+	for sqlRows.Next() {
+		var id float64
+		dest := make([]interface{}, len(computed))
+		scanArgs := []interface{}{&id}
+		for i := range dest {
+			scanArgs = append(scanArgs, &dest[i])
+		}
+		if err := sqlRows.Scan(scanArgs...); err != nil {
+			return nil, fmt.Errorf("computing fields: %w", err)
+		}
+		values[id] = dest
+	}
+	if err := sqlRows.Err(); err != nil {
+		return nil, fmt.Errorf("computing fields: %w", err)
+	}
+	for _, row := range rows {
+		id, _ := row["id"].(float64)
+		for i, field := range computed {
+			var v interface{}
+			if vals, ok := values[id]; ok {
+				v = vals[i]
+			}
+			if b, isBytes := v.([]byte); isBytes {
+				v = string(b)
+			}
+			row[field.Name] = v
 		}
-		results, queryError = query.All(ctx)
+	}
+	return rows, nil
+}
+
+// filterErrorResponse is the body returned with 400 when a filter fails
+// validation; Errors addresses each problem by its path in the filter array.
+type filterErrorResponse struct {
+	Error  string                     `json:"error"`
+	Errors filterast.ValidationErrors `json:"errors"`
+}
+
+// writeFilterError reports filter validation problems as a 400 with the
+// structured error list, and any other error as a 500.
+func writeFilterError(w http.ResponseWriter, err error) {
+	var validationErrs filterast.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		http.Error(w, fmt.Sprintf("Error parsing filter: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(filterErrorResponse{Error: "invalid filter", Errors: validationErrs})
+}
+
+// filterTargetRequest names the entity ("entity") or dynamic table ("table")
+// a filter is written against, for endpoints that work on the filter alone.
+type filterTargetRequest struct {
+	Entity string      `json:"entity"`
+	Table  string      `json:"table"`
+	Filter interface{} `json:"filter"`
+}
+
+// decodeFilterTarget decodes a filterTargetRequest and resolves the fields of
+// its entity or table. On failure it writes the error response and returns
+// ok == false.
+func decodeFilterTarget(w http.ResponseWriter, r *http.Request) (req filterTargetRequest, fields map[string]schematool.SchemaFieldDefinition, ok bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return req, nil, false
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Backend: Error decoding request body for %s: %v", r.URL.Path, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, nil, false
+	}
+	fields, ok = resolveFilterTarget(w, req)
+	return req, fields, ok
+}
+
+// resolveFilterTarget returns the fields of the request's entity or table.
+// On failure it writes the error response and returns ok == false.
+func resolveFilterTarget(w http.ResponseWriter, req filterTargetRequest) (fields map[string]schematool.SchemaFieldDefinition, ok bool) {
+	switch {
+	case req.Entity != "":
+		adapter, err := GetAdapter(req.Entity)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("No adapter for entity '%s'", req.Entity), http.StatusBadRequest)
+			return nil, false
+		}
+		return adapter.Fields(), true
+	case req.Table != "":
+		schema, err := dynamictablefilter.LoadTableSchema(req.Table)
+		if err != nil {
+			http.Error(w, "Schema not found for table "+req.Table, http.StatusBadRequest)
+			return nil, false
+		}
+		return schema.FieldMap, true
 	default:
-		log.Printf("Backend: Unsupported entity type for filtering: %s", requestBody.Entity)
+		http.Error(w, "Missing 'entity' or 'table' field in request body", http.StatusBadRequest)
+		return nil, false
+	}
+}
+
+// validateFilterHandler checks a filter against an ent entity ("entity") or a
+// dynamic table ("table") without running it, listing every problem found.
+func validateFilterHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody, fields, ok := decodeFilterTarget(w, r)
+	if !ok {
+		return
+	}
+	response := struct {
+		Valid  bool                       `json:"valid"`
+		Errors filterast.ValidationErrors `json:"errors"`
+	}{Valid: true, Errors: filterast.ValidationErrors{}}
+	if _, err := filterast.Parse(requestBody.Filter, fields); err != nil {
+		if !errors.As(err, &response.Errors) {
+			http.Error(w, fmt.Sprintf("Error validating filter: %v", err), http.StatusInternalServerError)
+			return
+		}
+		response.Valid = false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// normalizeFilterHandler returns the canonical, simplified form of a filter
+// as a DevExtreme array; invalid filters get the usual 400 error list.
+func normalizeFilterHandler(w http.ResponseWriter, r *http.Request) {
+	requestBody, fields, ok := decodeFilterTarget(w, r)
+	if !ok {
+		return
+	}
+	normalized, err := filterast.NormalizeFilter(requestBody.Filter, fields)
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"filter": normalized})
+}
+
+// queryPlanStep is one row of SQLite's EXPLAIN QUERY PLAN output.
+type queryPlanStep struct {
+	ID     int    `json:"id"`
+	Parent int    `json:"parent"`
+	Detail string `json:"detail"`
+}
+
+// explainFilterHandler shows what a filter does against an ent entity: the
+// WHERE clause and bound arguments built by its adapter, a plain-English
+// description, and SQLite's query plan for the resulting SELECT. Like
+// /filter it takes an optional "collation" overriding the string fields'.
+func explainFilterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var requestBody struct {
+		Entity    string      `json:"entity"`
+		Filter    interface{} `json:"filter"`
+		Collation string      `json:"collation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		log.Printf("Backend: Error decoding explain request body: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	adapter, err := GetAdapter(requestBody.Entity)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("No adapter for entity '%s'", requestBody.Entity), http.StatusBadRequest)
+		return
+	}
+	table, ok := entityTables[strings.ToLower(requestBody.Entity)]
+	if !ok {
 		http.Error(w, fmt.Sprintf("Unsupported entity type: %s", requestBody.Entity), http.StatusBadRequest)
 		return
 	}
-	if queryError != nil {
-		log.Printf("Backend: Error executing query for entity '%s': %v", requestBody.Entity, queryError)
-		http.Error(w, fmt.Sprintf("Error executing query: %v", queryError), http.StatusInternalServerError)
+	root, err := filterast.Parse(requestBody.Filter, adapter.Fields())
+	if err != nil {
+		writeFilterError(w, err)
+		return
+	}
+	if err := filterast.SetCollation(root, requestBody.Collation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pred, err := BuildPredicate(adapter, root)
+	if err != nil {
+		log.Printf("Backend: Error building predicate for entity '%s': %v", requestBody.Entity, err)
+		http.Error(w, fmt.Sprintf("Error building predicate: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := struct {
+		SQL         string          `json:"sql"`
+		Where       string          `json:"where"`
+		Args        []interface{}   `json:"args"`
+		Description string          `json:"description"`
+		QueryPlan   []queryPlanStep `json:"queryPlan"`
+	}{Args: []interface{}{}, Description: filterast.Describe(root), QueryPlan: []queryPlanStep{}}
+
+	selector := sql.Dialect(dialect.SQLite).Select("*").From(sql.Table(table))
+	if pred != nil {
+		selector.Where(pred)
+		response.Where, response.Args = (*sql.Predicate)(pred).Query()
+	}
+	query, args := selector.Query()
+	response.SQL = query
+
+	rows, err := db.QueryContext(r.Context(), "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		log.Printf("Backend: Error explaining query for entity '%s': %v", requestBody.Entity, err)
+		http.Error(w, fmt.Sprintf("Error explaining query: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var step queryPlanStep
+		var notUsed int
+		if err := rows.Scan(&step.ID, &step.Parent, &notUsed, &step.Detail); err != nil {
+			http.Error(w, fmt.Sprintf("Error reading query plan: %v", err), http.StatusInternalServerError)
+			return
+		}
+		response.QueryPlan = append(response.QueryPlan, step)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, fmt.Sprintf("Error reading query plan: %v", err), http.StatusInternalServerError)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(results)
+	json.NewEncoder(w).Encode(response)
+}
+
+// dynamicTableFilterHandler answers POST /dynamic-tables/{table}/filter with
+// the table's records matching the load options.
+func dynamicTableFilterHandler(w http.ResponseWriter, r *http.Request, tableName string) {
+	var requestBody loadOptions
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestBody); err != nil {
+		log.Printf("Error decoding filter request for dynamic table %s: %v", tableName, err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := requestBody.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	schema, errSchema := dynamictablefilter.LoadTableSchema(tableName)
+	if errSchema != nil {
+		log.Printf("Error loading schema for dynamic table %s during filter: %v", tableName, errSchema)
+		http.Error(w, "Schema not found for table "+tableName, http.StatusInternalServerError)
+		return
+	}
+	tableData, errData := dynamictablefilter.LoadTableData(tableName)
+	if errData != nil {
+		log.Printf("Error loading data for dynamic table %s during filter: %v", tableName, errData)
+		http.Error(w, "Data not found for table "+tableName, http.StatusInternalServerError)
+		return
+	}
+	root, errFilter := filterast.Parse(requestBody.Filter, schema.FieldMap)
+	if errFilter != nil {
+		log.Printf("Error filtering data for dynamic table %s: %v", tableName, errFilter)
+		writeFilterError(w, errFilter)
+		return
+	}
+	if err := filterast.SetCollation(root, requestBody.Collation); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filteredData := dynamictablefilter.MatchDynamicData(tableData, schema, root)
+	sorts, errSort := filterast.ParseSort(requestBody.Sort, schema.FieldMap)
+	if errSort != nil {
+		writeFilterError(w, errSort)
+		return
+	}
+	groups, errGroup := filterast.ParseGroup(requestBody.Group, schema.FieldMap)
+	if errGroup != nil {
+		writeFilterError(w, errGroup)
+		return
+	}
+	filterast.SetSortCollation(sorts, requestBody.Collation)
+	filterast.SetGroupCollation(groups, requestBody.Collation)
+	groupSummary, totalSummary, errSummary := requestBody.parseSummaries(schema.FieldMap)
+	if errSummary != nil {
+		writeFilterError(w, errSummary)
+		return
+	}
+	dynamictablefilter.SortDynamicData(filteredData, sorts)
+	if requestBody.RankByDistance {
+		dynamictablefilter.RankByFuzzyDistance(filteredData, filterast.FuzzyConditions(root))
+	}
+
+	result := loadResult{TotalCount: len(filteredData)}
+	if len(totalSummary) > 0 {
+		result.Summary = filterast.SummaryValues(totalSummary, dynamictablefilter.GroupDynamicData(filteredData, nil, totalSummary))
+	}
+	if len(groups) == 0 {
+		result.Data = pageRecords(filteredData, requestBody.Skip, requestBody.Take)
+		writeLoadResult(w, &requestBody, result)
+		return
+	}
+	counts := dynamictablefilter.GroupDynamicData(filteredData, groups, groupSummary)
+	page, groupCount, _ := groupPage(counts, groups, requestBody.Skip, requestBody.Take, func(skip, take int) ([]map[string]interface{}, error) {
+		return pageRecords(filteredData, skip, take), nil
+	})
+	result.Data, result.GroupCount = page, &groupCount
+	writeLoadResult(w, &requestBody, result)
 }
 
 func main() {
@@ -239,10 +735,18 @@ func main() {
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/filter", filterHandler)
+	mux.HandleFunc("/filter/validate", validateFilterHandler)
+	mux.HandleFunc("/filter/normalize", normalizeFilterHandler)
+	mux.HandleFunc("/filter/explain", explainFilterHandler)
+	mux.HandleFunc("/filter/distinct", distinctValuesHandler)
+	mux.HandleFunc("/fields", fieldConfigHandler)
+	mux.HandleFunc("/entities/", entityRecordHandler)
+	mux.HandleFunc("/entities/batch", batchSaveHandler)
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"http://localhost:3000", "http://localhost:8080"},
-		AllowedMethods: []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Content-Type", "If-Match"},
+		ExposedHeaders: []string{"ETag"},
 	})
 	handler := c.Handler(mux)
 
@@ -302,35 +806,7 @@ func main() {
 			return
 		}
 		if len(pathParts) == 2 && pathParts[1] == "filter" && r.Method == http.MethodPost {
-			var requestBody struct {
-				Filter interface{} `json:"filter"`
-			}
-			decoder := json.NewDecoder(r.Body)
-			if err := decoder.Decode(&requestBody); err != nil {
-				log.Printf("Error decoding filter request for dynamic table %s: %v", tableName, err)
-				http.Error(w, "Invalid request body", http.StatusBadRequest)
-				return
-			}
-			schema, errSchema := dynamictablefilter.LoadTableSchema(tableName)
-			if errSchema != nil {
-				log.Printf("Error loading schema for dynamic table %s during filter: %v", tableName, errSchema)
-				http.Error(w, "Schema not found for table "+tableName, http.StatusInternalServerError)
-				return
-			}
-			tableData, errData := dynamictablefilter.LoadTableData(tableName)
-			if errData != nil {
-				log.Printf("Error loading data for dynamic table %s during filter: %v", tableName, errData)
-				http.Error(w, "Data not found for table "+tableName, http.StatusInternalServerError)
-				return
-			}
-			filteredData, errFilter := dynamictablefilter.FilterDynamicData(tableData, schema, requestBody.Filter)
-			if errFilter != nil {
-				log.Printf("Error filtering data for dynamic table %s: %v", tableName, errFilter)
-				http.Error(w, "Error during filtering data for table "+tableName, http.StatusInternalServerError)
-				return
-			}
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(filteredData)
+			dynamicTableFilterHandler(w, r, tableName)
 			return
 		}
 		http.NotFound(w, r)
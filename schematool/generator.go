@@ -45,6 +45,21 @@ const SchemaDefinitionsDir = "./schema_definitions"
 type SchemaFieldDefinition struct {
 	Name string `json:"name"`
 	Type string `json:"type"`
+	// Expression makes the field computed: its value is derived from other
+	// fields (see package fieldexpr) instead of being stored.
+	Expression string `json:"expression,omitempty"`
+	// Collation sets how filters compare a string field's text, see
+	// filterast.Fold; empty means filterast.DefaultCollation.
+	Collation string `json:"collation,omitempty"`
+	// Caption is the label clients show for the field; empty derives it
+	// from the name. Format is a DevExtreme format such as "currency" or
+	// "yyyy-MM-dd"; empty uses the type's default.
+	Caption string `json:"caption,omitempty"`
+	Format  string `json:"format,omitempty"`
+	// Required means a stored field must have a value when a record is
+	// written, and Values, when set, lists the only values it may hold.
+	Required bool          `json:"required,omitempty"`
+	Values   []interface{} `json:"values,omitempty"`
 }
 
 type SchemaRequest struct {
@@ -95,11 +110,21 @@ func GenerateGoSchemaCode(req SchemaRequest) (string, error) {
 	sb.WriteString("\tent.Schema\n")
 	sb.WriteString("}\n\n")
 
+	// VersionMixin (ent/schema/version.go) adds the version the editing
+	// endpoints use for optimistic concurrency.
+	sb.WriteString(fmt.Sprintf("// Mixin of the %s.\n", sanitizedEntityTypeName))
+	sb.WriteString(fmt.Sprintf("func (%s) Mixin() []ent.Mixin {\n", sanitizedEntityTypeName))
+	sb.WriteString("\treturn []ent.Mixin{VersionMixin{}}\n")
+	sb.WriteString("}\n\n")
+
 	sb.WriteString(fmt.Sprintf("// Fields of the %s.\n", sanitizedEntityTypeName))
 	sb.WriteString(fmt.Sprintf("func (%s) Fields() []ent.Field {\n", sanitizedEntityTypeName))
 	sb.WriteString("\treturn []ent.Field{\n")
 
 	for _, f := range req.Fields {
+		if f.Expression != "" {
+			continue // computed fields are not stored
+		}
 		if f.Name == "" || f.Type == "" {
 			return "", fmt.Errorf("field name and type cannot be empty (field: %+v)", f)
 		}
@@ -160,6 +185,8 @@ func GenerateGoAdapterCode(req SchemaRequest) (string, error) {
 	sb.WriteString("\t\"strings\"\n")
 	sb.WriteString("\t\"time\"\n\n")
 	sb.WriteString(fmt.Sprintf("\t\"transaction-filter-backend/ent/%s\"\n", entityNameLower))
+	sb.WriteString("\t\"transaction-filter-backend/filterast\"\n")
+	sb.WriteString("\t\"transaction-filter-backend/schematool\"\n")
 	sb.WriteString(fmt.Sprintf("\t\"transaction-filter-backend/ent/predicate\" // For predicate.%s type alias\n", sanitizedEntityTypeName))
 	sb.WriteString("\t\"entgo.io/ent/dialect/sql\" \n")
 	sb.WriteString(")\n\n")
@@ -168,21 +195,36 @@ func GenerateGoAdapterCode(req SchemaRequest) (string, error) {
 	sb.WriteString(fmt.Sprintf("// %s implements the EntityAdapter for the %s entity.\n", adapterName, sanitizedEntityTypeName))
 	sb.WriteString(fmt.Sprintf("type %s struct{}\n\n", adapterName))
 
-	sb.WriteString(fmt.Sprintf("// GetPredicateForField constructs a predicate for %s.\n", sanitizedEntityTypeName))
-	sb.WriteString(fmt.Sprintf("func (ta *%s) GetPredicateForField(field string, op string, val interface{}) (PredicateFunc, error) {\n", adapterName))
-	sb.WriteString("\tfield = strings.ToLower(field)\n")
-	sb.WriteString("\tswitch field {\n")
+	sb.WriteString(fmt.Sprintf("// Fields returns the field definitions used to parse filters for %s.\n", sanitizedEntityTypeName))
+	sb.WriteString(fmt.Sprintf("func (ta *%s) Fields() map[string]schematool.SchemaFieldDefinition {\n", adapterName))
+	sb.WriteString("\treturn map[string]schematool.SchemaFieldDefinition{\n")
 	for _, f := range req.Fields {
+		if f.Expression != "" {
+			continue // computed fields need GenericEntAdapter
+		}
+		sb.WriteString(fmt.Sprintf("\t\t\"%s\": {Name: \"%s\", Type: \"%s\"},\n", strings.ToLower(f.Name), f.Name, f.Type))
+	}
+	sb.WriteString("\t}\n")
+	sb.WriteString("}\n\n")
+
+	sb.WriteString(fmt.Sprintf("// GetPredicateForCondition constructs a predicate for %s. The condition's\n", sanitizedEntityTypeName))
+	sb.WriteString("// operator is already validated and its value coerced to the field's Go type.\n")
+	sb.WriteString(fmt.Sprintf("func (ta *%s) GetPredicateForCondition(cond *filterast.Condition) (PredicateFunc, error) {\n", adapterName))
+	sb.WriteString("\tswitch strings.ToLower(cond.Field.Name) {\n")
+	for _, f := range req.Fields {
+		if f.Expression != "" {
+			continue
+		}
 		goFieldName := f.Name
 
 		sb.WriteString(fmt.Sprintf("\tcase \"%s\":\n", strings.ToLower(f.Name)))
 		sb.WriteString(fmt.Sprintf("\t\t// TODO: Implement predicate logic for field '%s' (type: %s)\n", f.Name, f.Type))
-		sb.WriteString(fmt.Sprintf("\t\t// Example for string EQ: return PredicateFunc(%s.%sEQ(val.(string))), nil\n", entityNameLower, goFieldName))
-		sb.WriteString(fmt.Sprintf("\t\t// Example for int GT: return PredicateFunc(%s.%sGT(val.(int))), nil\n", entityNameLower, goFieldName))
+		sb.WriteString(fmt.Sprintf("\t\t// Example for string EQ: return PredicateFunc(%s.%sEQ(cond.Value.(string))), nil\n", entityNameLower, goFieldName))
+		sb.WriteString(fmt.Sprintf("\t\t// Example for int GT: return PredicateFunc(%s.%sGT(cond.Value.(int))), nil\n", entityNameLower, goFieldName))
 		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"predicate for field '%s' (type %s) not fully implemented yet\")\n", f.Name, f.Type))
 	}
 	sb.WriteString("\tdefault:\n")
-	sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"unsupported field for %s: %%s\", field)\n", sanitizedEntityTypeName))
+	sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"unsupported field for %s: %%s\", cond.Field.Name)\n", sanitizedEntityTypeName))
 	sb.WriteString("\t}\n")
 	sb.WriteString("}\n\n")
 
@@ -219,6 +261,16 @@ func GenerateGoAdapterCode(req SchemaRequest) (string, error) {
 	sb.WriteString(fmt.Sprintf("\treturn PredicateFunc(%s.Not(predicate.%s(p)))\n", entityNameLower, sanitizedEntityTypeName))
 	sb.WriteString("}\n\n")
 
+	sb.WriteString(fmt.Sprintf("// GetColumnExpression returns the column used to sort %s by a field, or\n", sanitizedEntityTypeName))
+	sb.WriteString("// the date part expression of GenericEntAdapter when part is set.\n")
+	sb.WriteString(fmt.Sprintf("func (ta *%s) GetColumnExpression(field schematool.SchemaFieldDefinition, part string) string {\n", adapterName))
+	sb.WriteString("\tcolumn := strings.ToLower(field.Name)\n")
+	sb.WriteString("\tif part != \"\" {\n")
+	sb.WriteString("\t\treturn datePartExpression(column, part)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn column\n")
+	sb.WriteString("}\n\n")
+
 	sb.WriteString(fmt.Sprintf("func init() {\n"))
 	sb.WriteString(fmt.Sprintf("\t// Ensure this adapter is registered. The entity name should be lowercase.\n"))
 	sb.WriteString(fmt.Sprintf("\t// Note: You might need to make RegisterAdapter public if it's in another package,\n"))
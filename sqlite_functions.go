@@ -0,0 +1,141 @@
+package main
+
+import (
+	stdsql "database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"transaction-filter-backend/filterast"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteDriverName is the go-sqlite3 driver with the functions filters need
+// registered on every connection.
+const sqliteDriverName = "sqlite3_filters"
+
+var registerDriverOnce sync.Once
+
+// registerSQLiteDriver registers sqliteDriverName; openClient calls it
+// before opening a connection, which may happen in another package-level
+// init.
+func registerSQLiteDriver() {
+	registerDriverOnce.Do(func() {
+		stdsql.Register(sqliteDriverName, &sqlite3.SQLiteDriver{
+			ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+				if err := conn.RegisterFunc("regexp", sqliteRegexp, true); err != nil {
+					return err
+				}
+				if err := conn.RegisterFunc("fuzzy_distance", sqliteFuzzyDistance, true); err != nil {
+					return err
+				}
+				if err := conn.RegisterFunc("fold", sqliteFold, true); err != nil {
+					return err
+				}
+				if err := conn.RegisterFunc("tz_offset", sqliteTimezoneOffset, true); err != nil {
+					return err
+				}
+				return conn.RegisterFunc("bucket", sqliteBucket, true)
+			},
+		})
+	})
+}
+
+// sqliteText returns the text of a SQLite value; ok is false for NULL,
+// which go-sqlite3 passes as a nil []byte.
+func sqliteText(value interface{}) (s string, ok bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		if v == nil {
+			return "", false
+		}
+		return string(v), true
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+// sqliteRegexp implements SQLite's "value REGEXP pattern" operator, which
+// calls regexp(pattern, value), with Go's RE2 syntax so that "matches"
+// behaves the same as in dynamic tables. A NULL value yields NULL.
+func sqliteRegexp(pattern string, value interface{}) (interface{}, error) {
+	s, ok := sqliteText(value)
+	if !ok {
+		return nil, nil
+	}
+	re, err := filterast.CompilePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(s), nil
+}
+
+// sqliteFuzzyDistance implements fuzzy_distance(value, search, collation) with
+// filterast.FuzzyDistance, for the "fuzzy" operator and ranking by distance.
+// A NULL value yields NULL.
+func sqliteFuzzyDistance(value interface{}, search, collation string) interface{} {
+	s, ok := sqliteText(value)
+	if !ok {
+		return nil
+	}
+	return filterast.FuzzyDistance(s, search, collation)
+}
+
+// sqliteFold implements fold(value, collation) with filterast.Fold, giving
+// the key a string column is compared under. A NULL value yields NULL.
+func sqliteFold(value interface{}, collation string) interface{} {
+	s, ok := sqliteText(value)
+	if !ok {
+		return nil
+	}
+	return filterast.Fold(s, collation)
+}
+
+// sqliteTimezoneOffset implements tz_offset(value), the offset in seconds
+// of filterast.Timezone() from UTC at the time value, as stored by
+// go-sqlite3. It is deterministic only as long as the timezone does not
+// change, which it does once at startup. A NULL or unparsable value yields
+// NULL.
+func sqliteTimezoneOffset(value interface{}) interface{} {
+	s, ok := sqliteText(value)
+	if !ok {
+		return nil
+	}
+	s = strings.TrimSuffix(s, "Z")
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			_, offset := t.In(filterast.Timezone()).Zone()
+			return offset
+		}
+	}
+	return nil
+}
+
+// sqliteBucket implements bucket(value, interval) with filterast.Bucket, the
+// key of a numeric groupInterval. A NULL value yields NULL.
+func sqliteBucket(value, interval interface{}) interface{} {
+	v, ok := sqliteNumber(value)
+	width, _ := sqliteNumber(interval)
+	if !ok {
+		return nil
+	}
+	return filterast.Bucket(v, width)
+}
+
+// sqliteNumber returns the value of a SQLite INTEGER or REAL; ok is false
+// for anything else.
+func sqliteNumber(value interface{}) (f float64, ok bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	stdsql "database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"transaction-filter-backend/ent"
+	"transaction-filter-backend/ent/test3schema"
+	"transaction-filter-backend/filterast"
+
+	"entgo.io/ent/dialect/sql"
 	// For in-memory SQLite. No longer using enttest directly after TestMain change.
 	_ "github.com/mattn/go-sqlite3" // SQLite driver
 )
@@ -19,7 +28,8 @@ var testClient *ent.Client
 func TestMain(m *testing.M) {
 	log.Println("TestMain: START")
 	var errOpen error
-	testClient, errOpen = ent.Open("sqlite3", "file:ent_test_main?mode=memory&cache=shared&_fk=1")
+	var testDB *stdsql.DB
+	testClient, testDB, errOpen = openClient("file:ent_test_main?mode=memory&cache=shared&_fk=1")
 	if errOpen != nil {
 		log.Fatalf("failed opening connection to sqlite: %v", errOpen)
 	}
@@ -29,8 +39,8 @@ func TestMain(m *testing.M) {
 		log.Fatalf("failed creating schema resources: %v", err)
 	}
 
-	originalClient := client
-	client = testClient
+	originalClient, originalDB := client, db
+	client, db = testClient, testDB
 
 	// Adapters should be registered by their init() functions.
 	// e.g. init() in transaction_adapter.go, test1schema_adapter.go etc.
@@ -43,7 +53,7 @@ func TestMain(m *testing.M) {
 	code := m.Run()
 	log.Printf("TestMain: m.Run() finished with code %d.", code)
 
-	client = originalClient
+	client, db = originalClient, originalDB
 	log.Println("TestMain: Restored original client. Exiting.")
 	os.Exit(code)
 }
@@ -205,3 +215,912 @@ func TestFilterTransactions(t *testing.T) {
 		})
 	}
 }
+
+// queryTransactions runs filterInput through the registered transaction adapter
+// against the test database.
+func queryTransactions(t *testing.T, filterInput interface{}) ([]*ent.Transaction, error) {
+	t.Helper()
+	adapter, err := GetAdapter("transaction")
+	if err != nil {
+		t.Fatalf("transaction adapter not registered: %v", err)
+	}
+	pred, err := ParseFilterToPredicates(adapter, filterInput)
+	if err != nil {
+		return nil, err
+	}
+	query := testClient.Transaction.Query()
+	if pred != nil {
+		query = query.Where(func(s *sql.Selector) { s.Where(pred) })
+	}
+	return query.All(context.Background())
+}
+
+// transactionFilterCase is a filter run through queryTransactions together
+// with the expected number of matching test transactions.
+type transactionFilterCase struct {
+	name          string
+	filterInput   interface{}
+	expectedCount int
+	expectedError bool
+}
+
+func runTransactionFilterCases(t *testing.T, testCases []transactionFilterCase) {
+	t.Helper()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			transactions, err := queryTransactions(t, tc.filterInput)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error, got %d results", len(transactions))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(transactions) != tc.expectedCount {
+				t.Errorf("expected %d transactions, got %d", tc.expectedCount, len(transactions))
+			}
+		})
+	}
+}
+
+func TestParseFilterGroupPrecedence(t *testing.T) {
+	testCases := []transactionFilterCase{
+		{
+			// amount = 100 OR (amount = 200 AND location = 'Sampleburg'): 5 + 5.
+			// Left-to-right folding would yield 5 (only the amount = 200 rows).
+			name: "AND binds tighter than OR",
+			filterInput: []interface{}{
+				[]interface{}{"amount", "=", 100},
+				"or",
+				[]interface{}{"amount", "=", 200},
+				"and",
+				[]interface{}{"location", "=", "Sampleburg"},
+			},
+			expectedCount: 10,
+		},
+		{
+			name: "AND run before OR",
+			filterInput: []interface{}{
+				[]interface{}{"amount", "=", 200},
+				"and",
+				[]interface{}{"location", "=", "Sampleburg"},
+				"or",
+				[]interface{}{"amount", "=", 100},
+			},
+			expectedCount: 10,
+		},
+		{
+			name: "Empty sub-group is ignored",
+			filterInput: []interface{}{
+				[]interface{}{"amount", "=", 100},
+				"and",
+				[]interface{}{},
+			},
+			expectedCount: 5,
+		},
+		{
+			name:          "Dangling operator",
+			filterInput:   []interface{}{[]interface{}{"amount", "=", 100}, "and"},
+			expectedError: true,
+		},
+	}
+
+	runTransactionFilterCases(t, testCases)
+}
+
+func TestParseFilterShorthandForms(t *testing.T) {
+	testCases := []transactionFilterCase{
+		{
+			name:          "Two-element equality shorthand",
+			filterInput:   []interface{}{"location", "Testville"},
+			expectedCount: 10,
+		},
+		{
+			name: "Implicit AND between adjacent conditions",
+			filterInput: []interface{}{
+				[]interface{}{"amount", "=", 100},
+				[]interface{}{"location", "=", "Testville"},
+			},
+			expectedCount: 5,
+		},
+		{
+			name: "Implicit AND keeps precedence over explicit OR",
+			filterInput: []interface{}{
+				[]interface{}{"amount", 100},
+				[]interface{}{"amount", 200},
+				"or",
+				[]interface{}{"location", "Sampleburg"},
+			},
+			expectedCount: 10,
+		},
+		{
+			name:          "NOT around shorthand",
+			filterInput:   []interface{}{"!", []interface{}{"location", "Testville"}},
+			expectedCount: 40,
+		},
+		{
+			name: "NOT nested inside implicit AND group",
+			filterInput: []interface{}{
+				[]interface{}{"location", "Testville"},
+				[]interface{}{"!", []interface{}{"amount", "=", 100}},
+			},
+			expectedCount: 5,
+		},
+		{
+			name:          "Leading operator",
+			filterInput:   []interface{}{"and", []interface{}{"amount", "=", 100}},
+			expectedError: true,
+		},
+		{
+			name: "Consecutive operators",
+			filterInput: []interface{}{
+				[]interface{}{"amount", "=", 100}, "and", "or", []interface{}{"amount", "=", 200},
+			},
+			expectedError: true,
+		},
+	}
+
+	runTransactionFilterCases(t, testCases)
+}
+
+func TestParseFilterHeaderFilterOperators(t *testing.T) {
+	runTransactionFilterCases(t, []transactionFilterCase{
+		{
+			name:          "anyof on string field",
+			filterInput:   []interface{}{"location", "anyof", []interface{}{"Testville", "Sampleburg"}},
+			expectedCount: 20,
+		},
+		{
+			name:          "noneof on string field",
+			filterInput:   []interface{}{"location", "noneof", []interface{}{"Testville", "Sampleburg"}},
+			expectedCount: 30,
+		},
+		{
+			name:          "anyof on float field converts values",
+			filterInput:   []interface{}{"amount", "anyof", []interface{}{100, "200"}},
+			expectedCount: 10,
+		},
+		{
+			name:          "anyof with empty array matches nothing",
+			filterInput:   []interface{}{"amount", "anyof", []interface{}{}},
+			expectedCount: 0,
+		},
+		{
+			name:          "noneof with empty array matches everything",
+			filterInput:   []interface{}{"amount", "noneof", []interface{}{}},
+			expectedCount: 50,
+		},
+		{
+			name:          "anyof with non-array value",
+			filterInput:   []interface{}{"location", "anyof", "Testville"},
+			expectedError: true,
+		},
+		{
+			name:          "anyof with unconvertible value",
+			filterInput:   []interface{}{"amount", "anyof", []interface{}{"lots"}},
+			expectedError: true,
+		},
+	})
+}
+
+func TestParseFilterWholeDayDates(t *testing.T) {
+	// Transaction i is dated 2024-01-01 + i days at i%24 hours, so 2024-01-05
+	// holds exactly one transaction at 04:52 UTC.
+	runTransactionFilterCases(t, []transactionFilterCase{
+		{name: "= date matches the whole day", filterInput: []interface{}{"date", "=", "2024-01-05"}, expectedCount: 1},
+		{name: "= timestamp stays exact", filterInput: []interface{}{"date", "=", "2024-01-05T00:00:00Z"}, expectedCount: 0},
+		{name: "<> date excludes the whole day", filterInput: []interface{}{"date", "<>", "2024-01-05"}, expectedCount: 49},
+		{name: "< date excludes the day", filterInput: []interface{}{"date", "<", "2024-01-05"}, expectedCount: 4},
+		{name: "<= date includes the day", filterInput: []interface{}{"date", "<=", "2024-01-05"}, expectedCount: 5},
+		{name: "> date excludes the day", filterInput: []interface{}{"date", ">", "2024-01-05"}, expectedCount: 45},
+		{name: ">= date includes the day", filterInput: []interface{}{"date", ">=", "2024-01-05"}, expectedCount: 46},
+		{name: "between dates includes the last day", filterInput: []interface{}{"date", "between", []interface{}{"2024-01-02", "2024-01-05"}}, expectedCount: 4},
+		{name: "anyof dates", filterInput: []interface{}{"date", "anyof", []interface{}{"2024-01-05", "2024-01-07"}}, expectedCount: 2},
+		{name: "NOT = date", filterInput: []interface{}{"!", []interface{}{"date", "=", "2024-01-05"}}, expectedCount: 49},
+	})
+
+	t.Run("days follow the configured timezone", func(t *testing.T) {
+		// In UTC-6, 2024-01-05 runs from 06:00 UTC on the 5th to 06:00 UTC on
+		// the 6th: it misses the 04:52 transaction and catches the 05:05 one.
+		filterast.SetTimezone(time.FixedZone("UTC-6", -6*60*60))
+		defer filterast.SetTimezone(time.UTC)
+		transactions, err := queryTransactions(t, []interface{}{"date", "=", "2024-01-05"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(transactions) != 1 || transactions[0].Name != "Test Trans 5" {
+			t.Errorf("expected only Test Trans 5, got %v", transactions)
+		}
+	})
+}
+
+func TestParseFilterDateParts(t *testing.T) {
+	// Transactions run daily from Monday 2024-01-01 to 2024-02-19, transaction
+	// i at hour i%24.
+	runTransactionFilterCases(t, []transactionFilterCase{
+		{name: "weekend days", filterInput: []interface{}{"date.DayOfWeek", "anyof", []interface{}{0, 6}}, expectedCount: 14},
+		{name: "weekdays", filterInput: []interface{}{"date.dayofweek", "between", []interface{}{1, 5}}, expectedCount: 36},
+		{name: "month", filterInput: []interface{}{"date.Month", "=", 2}, expectedCount: 19},
+		{name: "quarter", filterInput: []interface{}{"date.Quarter", "=", 4}, expectedCount: 0},
+		{name: "year", filterInput: []interface{}{"date.Year", "=", "2024"}, expectedCount: 50},
+		{name: "afternoon hours", filterInput: []interface{}{"date.Hour", ">=", 12}, expectedCount: 24},
+		{name: "first days of each month", filterInput: []interface{}{"date.Day", "<=", 5}, expectedCount: 10},
+		{name: "combined with plain fields", filterInput: []interface{}{[]interface{}{"date.Month", "=", 1}, "and", []interface{}{"amount", ">", 500}}, expectedCount: 15},
+		{name: "part of a non-time field", filterInput: []interface{}{"amount.Year", "=", 2024}, expectedError: true},
+		{name: "unknown part", filterInput: []interface{}{"date.Week", "=", 1}, expectedError: true},
+	})
+
+	t.Run("parts follow the configured timezone", func(t *testing.T) {
+		// In UTC-6 the 04:52 UTC transaction of 2024-01-05 falls on the 4th,
+		// so the parts select the same day as the whole-day value.
+		filterast.SetTimezone(time.FixedZone("UTC-6", -6*60*60))
+		defer filterast.SetTimezone(time.UTC)
+		byParts, err := queryTransactions(t, []interface{}{[]interface{}{"date.Month", "=", 1}, "and", []interface{}{"date.Day", "=", 5}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		byDay, err := queryTransactions(t, []interface{}{"date", "=", "2024-01-05"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(byParts) != 1 || len(byDay) != 1 || byParts[0].ID != byDay[0].ID {
+			t.Errorf("expected the same single transaction, got %v by parts and %v by day", byParts, byDay)
+		}
+	})
+}
+
+func TestParseFilterPatternOperators(t *testing.T) {
+	runTransactionFilterCases(t, []transactionFilterCase{
+		{name: "matches anchored regex", filterInput: []interface{}{"name", "matches", `^Test Trans \d$`}, expectedCount: 10},
+		{name: "matches alternation", filterInput: []interface{}{"name", "matches", `Trans (1|2)\d$`}, expectedCount: 20},
+		{name: "matches is case-sensitive", filterInput: []interface{}{"name", "matches", `^test`}, expectedCount: 0},
+		{name: "negated matches", filterInput: []interface{}{"!", []interface{}{"name", "matches", `^Test Trans \d$`}}, expectedCount: 40},
+		{name: "like with percent", filterInput: []interface{}{"location", "like", "%ville"}, expectedCount: 10},
+		{name: "like is case-sensitive", filterInput: []interface{}{"location", "like", "%VILL%"}, expectedCount: 0},
+		{name: "like with underscore", filterInput: []interface{}{"name", "like", "Test Trans 4_"}, expectedCount: 10},
+		{name: "like matches the whole value", filterInput: []interface{}{"name", "like", "Test Trans"}, expectedCount: 0},
+		{name: "invalid regex", filterInput: []interface{}{"name", "matches", "(["}, expectedError: true},
+		{name: "matches on a number", filterInput: []interface{}{"amount", "matches", "1.*"}, expectedError: true},
+	})
+}
+
+func TestParseFilterFuzzy(t *testing.T) {
+	fuzzy := func(field, value string, distance int) interface{} {
+		return []interface{}{field, "fuzzy", map[string]interface{}{"value": value, "distance": float64(distance)}}
+	}
+	runTransactionFilterCases(t, []transactionFilterCase{
+		{name: "one typo", filterInput: fuzzy("location", "Testvile", 1), expectedCount: 10},
+		{name: "follows the exact collation", filterInput: fuzzy("location", "SAMPLEBURG", 0), expectedCount: 0},
+		{name: "exact word", filterInput: fuzzy("location", "Sampleburg", 0), expectedCount: 10},
+		{name: "matches a word of the value", filterInput: fuzzy("location", "Vilage", 1), expectedCount: 10},
+		{name: "multi-word search", filterInput: fuzzy("location", "Alfa Twn", 3), expectedCount: 10},
+		{name: "too far", filterInput: fuzzy("location", "Testvile", 0), expectedCount: 0},
+		{name: "negated", filterInput: []interface{}{"!", fuzzy("location", "Testvile", 1)}, expectedCount: 40},
+		{name: "missing value", filterInput: []interface{}{"location", "fuzzy", map[string]interface{}{"distance": 1.0}}, expectedError: true},
+		{name: "fuzzy on a number", filterInput: fuzzy("amount", "100", 1), expectedError: true},
+	})
+}
+
+func TestFilterRankByDistance(t *testing.T) {
+	body := `{"entity": "transaction", "filter": ["location", "fuzzy", {"value": "Testvill", "distance": 8}], "sort": ["date"], "rankByDistance": true}`
+	rec := httptest.NewRecorder()
+	filterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Data []Transaction `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("response is not a load result: %v: %s", err, rec.Body.String())
+	}
+	rows := result.Data
+	if len(rows) == 0 || rows[0].Location != "Testville" {
+		t.Fatalf("expected Testville first, got %+v", rows)
+	}
+	for i := 1; i < len(rows); i++ {
+		prev, curr := filterast.FuzzyDistance(rows[i-1].Location, "Testvill", filterast.CollationExact), filterast.FuzzyDistance(rows[i].Location, "Testvill", filterast.CollationExact)
+		if prev > curr || (prev == curr && rows[i-1].Date.After(rows[i].Date)) {
+			t.Fatalf("rows %d and %d are out of order: %+v, %+v", i-1, i, rows[i-1], rows[i])
+		}
+	}
+}
+
+func TestFilterCollations(t *testing.T) {
+	ctx := context.Background()
+	date := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"Café Zoë", "CAFE ZOE", "Straße 9"} {
+		testClient.Transaction.Create().SetAmount(1).SetDate(date).SetName(name).
+			SetLocation("Zürich").SetCategory("Collation").SetType("Test Debit").SaveX(ctx)
+	}
+	defer testClient.Transaction.Delete().Where(func(s *sql.Selector) { s.Where(sql.EQ("category", "Collation")) }).ExecX(ctx)
+
+	testCases := []struct {
+		name          string
+		filter        string
+		collation     string
+		expectedNames []string
+	}{
+		{name: "default is exact", filter: `["name", "=", "café zoë"]`, expectedNames: []string{}},
+		{name: "case-insensitive", filter: `["name", "=", "café zoë"]`, collation: "case-insensitive", expectedNames: []string{"Café Zoë"}},
+		{name: "case-insensitive folds ß", filter: `["name", "startswith", "STRASSE"]`, collation: "case-insensitive", expectedNames: []string{"Straße 9"}},
+		{name: "exact", filter: `["name", "contains", "Caf"]`, collation: "exact", expectedNames: []string{"Café Zoë"}},
+		{name: "exact like", filter: `["name", "like", "caf%"]`, collation: "exact", expectedNames: []string{}},
+		{name: "accent-insensitive", filter: `["name", "anyof", ["cafe zoe"]]`, collation: "accent-insensitive", expectedNames: []string{"Café Zoë", "CAFE ZOE"}},
+		{name: "accent-insensitive endswith", filter: `["name", "endswith", "ZOE"]`, collation: "accent-insensitive", expectedNames: []string{"Café Zoë", "CAFE ZOE"}},
+		{name: "accent-insensitive fuzzy", filter: `["name", "fuzzy", {"value": "kafe", "distance": 1}]`, collation: "accent-insensitive", expectedNames: []string{"Café Zoë", "CAFE ZOE"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"entity": "transaction", "filter": [["category", "=", "Collation"], "and", %s], "collation": %q}`, tc.filter, tc.collation)
+			rec := httptest.NewRecorder()
+			filterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var result struct {
+				Data []Transaction `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("response is not a load result: %v: %s", err, rec.Body.String())
+			}
+			names := make([]string, len(result.Data))
+			for i, row := range result.Data {
+				names[i] = row.Name
+			}
+			if fmt.Sprint(names) != fmt.Sprint(tc.expectedNames) {
+				t.Errorf("expected %v, got %v", tc.expectedNames, names)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	filterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(`{"entity": "transaction", "collation": "binary"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown collation, got %d", rec.Code)
+	}
+}
+
+func TestParseFilterNullComparisons(t *testing.T) {
+	ctx := context.Background()
+	published := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	testClient.Test3Schema.Create().SetSku("NULL-A").SetShortDescription("described").SetPublishedAt(published).SaveX(ctx)
+	testClient.Test3Schema.Create().SetSku("NULL-B").SetShortDescription("").SaveX(ctx)
+	testClient.Test3Schema.Create().SetSku("NULL-C").SaveX(ctx)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	adapter, err := GetAdapter("test3schema")
+	if err != nil {
+		t.Fatalf("test3schema adapter not registered: %v", err)
+	}
+	testCases := []struct {
+		name          string
+		filterInput   interface{}
+		expectedCount int
+		expectedError bool
+	}{
+		{name: "= null", filterInput: []interface{}{"published_at", "=", nil}, expectedCount: 2},
+		{name: "<> null", filterInput: []interface{}{"published_at", "<>", nil}, expectedCount: 1},
+		{name: "Shorthand null", filterInput: []interface{}{"short_description", nil}, expectedCount: 1},
+		{name: "isblank on string includes empty", filterInput: []interface{}{"short_description", "isblank", nil}, expectedCount: 2},
+		{name: "isnotblank on string", filterInput: []interface{}{"short_description", "isnotblank", nil}, expectedCount: 1},
+		{name: "isblank on time", filterInput: []interface{}{"published_at", "isblank", nil}, expectedCount: 2},
+		{name: "Ordering against null", filterInput: []interface{}{"published_at", ">", nil}, expectedError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pred, err := ParseFilterToPredicates(adapter, tc.filterInput)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error for filter %+v", tc.filterInput)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			count, err := testClient.Test3Schema.Query().Where(func(s *sql.Selector) { s.Where(pred) }).Count(ctx)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if count != tc.expectedCount {
+				t.Errorf("expected %d records, got %d", tc.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestParseFilterFieldComparisons(t *testing.T) {
+	ctx := context.Background()
+	march1 := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	testClient.Test3Schema.Create().SetSku("CMP-A").SetShortDescription("CMP-A").SetCostPrice(10).SetRetailPrice(15).SetStockCount(20).
+		SetPublishedAt(march1).SetLastOrderedAt(march1.AddDate(0, 0, 4)).SaveX(ctx)
+	testClient.Test3Schema.Create().SetSku("CMP-B").SetShortDescription("other").SetCostPrice(20).SetRetailPrice(18).SetStockCount(5).
+		SetPublishedAt(march1).SetLastOrderedAt(march1.AddDate(0, -1, 0)).SaveX(ctx)
+	testClient.Test3Schema.Create().SetSku("CMP-C").SetCostPrice(5).SetRetailPrice(5).SetStockCount(5).SaveX(ctx)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	adapter, err := GetAdapter("test3schema")
+	if err != nil {
+		t.Fatalf("test3schema adapter not registered: %v", err)
+	}
+	ref := func(name string) map[string]interface{} { return map[string]interface{}{"field": name} }
+	testCases := []struct {
+		name          string
+		filterInput   interface{}
+		expectedCount int
+		expectedError bool
+	}{
+		{name: "float > float", filterInput: []interface{}{"retail_price", ">", ref("cost_price")}, expectedCount: 1},
+		{name: "float <= float", filterInput: []interface{}{"retail_price", "<=", ref("COST_PRICE")}, expectedCount: 2},
+		{name: "shorthand equality", filterInput: []interface{}{"retail_price", ref("cost_price")}, expectedCount: 1},
+		{name: "int against float", filterInput: []interface{}{"stock_count", ">", ref("cost_price")}, expectedCount: 1},
+		{name: "time against time", filterInput: []interface{}{"last_ordered_at", ">", ref("published_at")}, expectedCount: 1},
+		{name: "nulls never match", filterInput: []interface{}{"last_ordered_at", "<>", ref("published_at")}, expectedCount: 2},
+		{name: "negated comparison", filterInput: []interface{}{"!", []interface{}{"retail_price", ">", ref("cost_price")}}, expectedCount: 2},
+		{name: "date parts", filterInput: []interface{}{"published_at.Month", "=", ref("last_ordered_at.Month")}, expectedCount: 1},
+		{name: "string fields", filterInput: []interface{}{"sku", "=", ref("short_description")}, expectedCount: 1},
+		{name: "incompatible types", filterInput: []interface{}{"sku", "=", ref("cost_price")}, expectedError: true},
+		{name: "unknown referenced field", filterInput: []interface{}{"cost_price", "<", ref("wholesale_price")}, expectedError: true},
+		{name: "operator without field form", filterInput: []interface{}{"sku", "contains", ref("tags")}, expectedError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pred, err := ParseFilterToPredicates(adapter, tc.filterInput)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("expected an error for filter %+v", tc.filterInput)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			count, err := testClient.Test3Schema.Query().Where(func(s *sql.Selector) { s.Where(pred) }).Count(ctx)
+			if err != nil {
+				t.Fatalf("query failed: %v", err)
+			}
+			if count != tc.expectedCount {
+				t.Errorf("expected %d records, got %d", tc.expectedCount, count)
+			}
+		})
+	}
+}
+
+func TestFilterComputedFields(t *testing.T) {
+	ctx := context.Background()
+	testClient.Test3Schema.Create().SetSku("CF-A").SetProductName("Lamp").SetCostPrice(10).SetRetailPrice(15).SaveX(ctx)
+	testClient.Test3Schema.Create().SetSku("CF-B").SetProductName("Desk").SetCostPrice(20).SetRetailPrice(28).SaveX(ctx)
+	testClient.Test3Schema.Create().SetSku("CF-C").SetProductName("Chair").SetCostPrice(5).SetRetailPrice(6).SaveX(ctx)
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedLabels []string
+		expectedMargin []float64
+	}{
+		{
+			name:           "filter and sort on an arithmetic field",
+			body:           `{"entity": "test3schema", "filter": ["margin", ">", 2], "sort": [{"selector": "margin", "desc": true}]}`,
+			expectedLabels: []string{"CF-B Desk", "CF-A Lamp"},
+			expectedMargin: []float64{8, 5},
+		},
+		{
+			name:           "filter on a string field, sort ascending",
+			body:           `{"entity": "test3schema", "filter": ["full_label", "endswith", "r"], "sort": ["margin"]}`,
+			expectedLabels: []string{"CF-C Chair"},
+			expectedMargin: []float64{1},
+		},
+		{
+			name:           "sort by a stored field",
+			body:           `{"entity": "test3schema", "sort": [{"selector": "cost_price"}]}`,
+			expectedLabels: []string{"CF-C Chair", "CF-A Lamp", "CF-B Desk"},
+			expectedMargin: []float64{1, 5, 8},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			filterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(tc.body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var result struct {
+				Data []struct {
+					FullLabel string  `json:"full_label"`
+					Margin    float64 `json:"margin"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatalf("response is not a load result: %v: %s", err, rec.Body.String())
+			}
+			rows := result.Data
+			if len(rows) != len(tc.expectedLabels) {
+				t.Fatalf("expected %d rows, got %s", len(tc.expectedLabels), rec.Body.String())
+			}
+			for i, row := range rows {
+				if row.FullLabel != tc.expectedLabels[i] || row.Margin != tc.expectedMargin[i] {
+					t.Errorf("row %d: expected %q with margin %v, got %q with margin %v", i, tc.expectedLabels[i], tc.expectedMargin[i], row.FullLabel, row.Margin)
+				}
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	filterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(`{"entity": "test3schema", "sort": ["nosuchfield"]}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown sort field, got %d", rec.Code)
+	}
+}
+
+func TestFilterValidationResponses(t *testing.T) {
+	invalidFilter := `[["amount", ">", "lots"], "and", ["nosuchfield", "=", 1]]`
+	testCases := []struct {
+		name           string
+		handler        http.HandlerFunc
+		body           string
+		expectedStatus int
+		expectedPaths  []string
+	}{
+		{
+			name:           "validate reports every problem",
+			handler:        validateFilterHandler,
+			body:           `{"entity": "transaction", "filter": ` + invalidFilter + `}`,
+			expectedStatus: http.StatusOK,
+			expectedPaths:  []string{"[0][2]", "[2][0]"},
+		},
+		{
+			name:           "validate accepts a good filter",
+			handler:        validateFilterHandler,
+			body:           `{"entity": "transaction", "filter": ["amount", ">", 100]}`,
+			expectedStatus: http.StatusOK,
+			expectedPaths:  []string{},
+		},
+		{
+			name:           "validate against a dynamic table",
+			handler:        validateFilterHandler,
+			body:           `{"table": "test1", "filter": ["unit_price", "contains", "9"]}`,
+			expectedStatus: http.StatusOK,
+			expectedPaths:  []string{"[1]"},
+		},
+		{
+			name:           "filter returns 400 with the same errors",
+			handler:        filterHandler,
+			body:           `{"entity": "transaction", "filter": ` + invalidFilter + `}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedPaths:  []string{"[0][2]", "[2][0]"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tc.handler(rec, httptest.NewRequest(http.MethodPost, "/filter", strings.NewReader(tc.body)))
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tc.expectedStatus, rec.Code, rec.Body.String())
+			}
+			var response struct {
+				Valid  *bool                      `json:"valid"`
+				Errors filterast.ValidationErrors `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("response is not JSON: %v: %s", err, rec.Body.String())
+			}
+			if response.Valid != nil && *response.Valid != (len(tc.expectedPaths) == 0) {
+				t.Errorf("expected valid=%v, got %v", len(tc.expectedPaths) == 0, *response.Valid)
+			}
+			if len(response.Errors) != len(tc.expectedPaths) {
+				t.Fatalf("expected %d errors, got %+v", len(tc.expectedPaths), response.Errors)
+			}
+			for i, path := range tc.expectedPaths {
+				if response.Errors[i].Path != path {
+					t.Errorf("error %d: expected path %s, got %s", i, path, response.Errors[i].Path)
+				}
+			}
+		})
+	}
+}
+
+func TestExplainFilter(t *testing.T) {
+	body := `{"entity": "transaction", "filter": [["amount", ">", 100], "and", [["category", "contains", "food"], "or", ["!", ["location", "=", null]]]]}`
+	rec := httptest.NewRecorder()
+	explainFilterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter/explain", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		SQL         string        `json:"sql"`
+		Where       string        `json:"where"`
+		Args        []interface{} `json:"args"`
+		Description string        `json:"description"`
+		QueryPlan   []struct {
+			Detail string `json:"detail"`
+		} `json:"queryPlan"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if !strings.Contains(response.SQL, "FROM `transactions` WHERE") || !strings.Contains(response.SQL, response.Where) {
+		t.Errorf("expected full SELECT containing the WHERE clause, got %q / %q", response.SQL, response.Where)
+	}
+	if len(response.Args) != 2 || response.Args[0] != 100.0 {
+		t.Errorf("expected bound args [100 %%food%%], got %v", response.Args)
+	}
+	expected := "Amount is greater than 100 and (Category contains 'food' or not (Location is null))"
+	if response.Description != expected {
+		t.Errorf("expected description %q, got %q", expected, response.Description)
+	}
+	if len(response.QueryPlan) == 0 || !strings.Contains(response.QueryPlan[0].Detail, "transactions") {
+		t.Errorf("expected a query plan scanning transactions, got %+v", response.QueryPlan)
+	}
+
+	t.Run("collation and timezone", func(t *testing.T) {
+		filterast.SetTimezone(time.FixedZone("UTC+2", 2*60*60))
+		defer filterast.SetTimezone(time.UTC)
+		body := `{"entity": "transaction", "filter": [["name", "=", "test"], "and", ["date", ">", "2024-01-05"]], "collation": "case-insensitive"}`
+		rec := httptest.NewRecorder()
+		explainFilterHandler(rec, httptest.NewRequest(http.MethodPost, "/filter/explain", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("response is not JSON: %v", err)
+		}
+		expected := "Name is 'test' (case-insensitive) and Date is greater than 2024-01-05"
+		if response.Description != expected || !strings.Contains(response.Where, "fold(") {
+			t.Errorf("expected description %q with a folded comparison, got %q / %q", expected, response.Description, response.Where)
+		}
+	})
+}
+
+func TestFieldConfig(t *testing.T) {
+	rec := httptest.NewRecorder()
+	fieldConfigHandler(rec, httptest.NewRequest(http.MethodGet, "/fields?entity=test3schema", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response struct {
+		Fields           []fieldConfig     `json:"fields"`
+		CustomOperations []customOperation `json:"customOperations"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if len(response.Fields) != 14 || response.Fields[0].DataField != "sku" || response.Fields[13].DataField != "stock_value" {
+		t.Fatalf("expected the 14 fields in declared order, got %+v", response.Fields)
+	}
+	sku, price, published, margin := response.Fields[0], response.Fields[5], response.Fields[8], response.Fields[11]
+	if sku.Caption != "SKU" || len(sku.ValidationRules) != 1 || sku.ValidationRules[0].Type != "required" || !sku.AllowEditing {
+		t.Errorf("expected sku to be an editable required field captioned SKU, got %+v", sku)
+	}
+	if !strings.Contains(strings.Join(sku.FilterOperations, ","), "fuzzy") {
+		t.Errorf("expected string operations for sku, got %v", sku.FilterOperations)
+	}
+	if price.Caption != "Retail Price" || price.DataType != "number" || price.Format != "currency" {
+		t.Errorf("unexpected retail_price config %+v", price)
+	}
+	if published.DataType != "datetime" || published.Format == "" || strings.Contains(strings.Join(published.FilterOperations, ","), "contains") {
+		t.Errorf("unexpected published_at config %+v", published)
+	}
+	if margin.AllowEditing || margin.DataType != "number" {
+		t.Errorf("expected the computed margin to be a read-only number, got %+v", margin)
+	}
+	if len(response.CustomOperations) != 3 {
+		t.Errorf("expected the custom string operations, got %+v", response.CustomOperations)
+	}
+
+	rec = httptest.NewRecorder()
+	fieldConfigHandler(rec, httptest.NewRequest(http.MethodGet, "/fields?entity=transaction", nil))
+	if !strings.Contains(rec.Body.String(), `"lookup":{"dataSource":["Debit","Credit"]}`) {
+		t.Errorf("expected a lookup for the transaction type, got %s", rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	fieldConfigHandler(rec, httptest.NewRequest(http.MethodGet, "/fields?table=test1", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"allowEditing":true`) {
+		t.Errorf("expected read-only fields for a dynamic table, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = httptest.NewRecorder()
+	fieldConfigHandler(rec, httptest.NewRequest(http.MethodGet, "/fields?entity=nosuchentity", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown entity, got %d", rec.Code)
+	}
+}
+
+func TestEntityWrites(t *testing.T) {
+	ctx := context.Background()
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+	ifMatch := ""
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		entityRecordHandler(rec, req)
+		return rec
+	}
+	errorFields := func(rec *httptest.ResponseRecorder) []string {
+		var response filterErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("response is not JSON: %v: %s", err, rec.Body.String())
+		}
+		fields := make([]string, len(response.Errors))
+		for i, e := range response.Errors {
+			fields[i] = e.Field
+		}
+		return fields
+	}
+
+	rec := send(http.MethodPost, "/entities/test3schema", `{"values": {"sku": "W-1", "Product_Name": "Widget", "retail_price": "12.5", "stock_count": 3, "tags": null}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created map[string]interface{}
+	json.Unmarshal(rec.Body.Bytes(), &created)
+	if created["product_name"] != "Widget" || created["retail_price"] != 12.5 || created["margin"] != 12.5 {
+		t.Errorf("expected the created record with its computed margin, got %v", created)
+	}
+	id := int(created["id"].(float64))
+	path := fmt.Sprintf("/entities/test3schema/%d", id)
+	if ifMatch = rec.Header().Get("ETag"); ifMatch != `"1"` || created["version"] != 1.0 {
+		t.Fatalf("expected a new record at version 1, got ETag %s and %v", ifMatch, created)
+	}
+
+	invalid := []struct {
+		name, method, path, body string
+		fields                   []string
+	}{
+		{"missing required field", http.MethodPost, "/entities/test3schema", `{"values": {"product_name": "Nameless"}}`, []string{"sku"}},
+		{"unknown and computed fields", http.MethodPost, "/entities/test3schema", `{"values": {"sku": "W-2", "margin": 1, "nosuch": 1}}`, []string{"margin", "nosuch"}},
+		{"wrong type", http.MethodPut, path, `{"values": {"stock_count": "many"}}`, []string{"stock_count"}},
+		{"empty required field", http.MethodPut, path, `{"values": {"sku": ""}}`, []string{"sku"}},
+		{"null in a field that is not optional", http.MethodPut, path, `{"values": {"product_name": null}}`, []string{"product_name"}},
+		{"value outside the allowed values", http.MethodPost, "/entities/transaction",
+			`{"values": {"date": "2024-05-01", "amount": 1, "name": "n", "location": "l", "category": "c", "type": "Other"}}`, []string{"type"}},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := send(tc.method, tc.path, tc.body)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected status 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if got := errorFields(rec); fmt.Sprint(got) != fmt.Sprint(tc.fields) {
+				t.Errorf("expected errors for %v, got %v", tc.fields, got)
+			}
+		})
+	}
+
+	if rec := send(http.MethodPost, "/entities/test3schema", `{"values": {"sku": "W-1"}}`); rec.Code != http.StatusConflict {
+		t.Errorf("expected status 409 for a duplicate sku, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := send(http.MethodPut, path, `{"key": 0, "values": {}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a key not matching the URL, got %d", rec.Code)
+	}
+	rec = send(http.MethodPut, path, fmt.Sprintf(`{"key": %d, "values": {"stock_count": 7, "short_description": null}}`, id))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	stored := testClient.Test3Schema.GetX(ctx, id)
+	if stored.StockCount != 7 || stored.ProductName != "Widget" || stored.ShortDescription != "" {
+		t.Errorf("expected only stock_count to change, got %+v", stored)
+	}
+	if etag := rec.Header().Get("ETag"); etag != `"2"` || stored.Version != 2 {
+		t.Errorf("expected the update to move the record to version 2, got ETag %s and version %d", etag, stored.Version)
+	}
+
+	for _, method := range []string{http.MethodPut, http.MethodDelete} {
+		rec := send(method, path, `{"values": {"stock_count": 1}}`)
+		var current map[string]interface{}
+		json.Unmarshal(rec.Body.Bytes(), &current)
+		if rec.Code != http.StatusConflict || current["version"] != 2.0 || current["stock_count"] != 7.0 || rec.Header().Get("ETag") != `"2"` {
+			t.Errorf("%s at a stale version: expected 409 with the current record, got %d: %s", method, rec.Code, rec.Body.String())
+		}
+	}
+	ifMatch = ""
+	if rec := send(http.MethodDelete, path, ""); rec.Code != http.StatusPreconditionRequired {
+		t.Errorf("expected status 428 without If-Match, got %d", rec.Code)
+	}
+	for _, tc := range []struct{ ifMatch, etag string }{{`W/"2"`, `"3"`}, {"*", `"4"`}} {
+		ifMatch = tc.ifMatch
+		if rec := send(http.MethodPut, path, `{"values": {"stock_count": 8}}`); rec.Code != http.StatusOK || rec.Header().Get("ETag") != tc.etag {
+			t.Errorf("update with If-Match %s: expected status 200 and ETag %s, got %d and %s: %s", tc.ifMatch, tc.etag, rec.Code, rec.Header().Get("ETag"), rec.Body.String())
+		}
+	}
+	if rec := send(http.MethodDelete, path, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected status 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		if rec := send(method, path, `{"values": {}}`); rec.Code != http.StatusNotFound {
+			t.Errorf("%s of a deleted record with If-Match *: expected status 404, got %d", method, rec.Code)
+		}
+	}
+	if rec := send(http.MethodGet, "/entities/nosuchentity/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown entity, got %d", rec.Code)
+	}
+}
+
+func TestBatchSave(t *testing.T) {
+	ctx := context.Background()
+	defer testClient.Test3Schema.Delete().ExecX(ctx)
+	existing := testClient.Test3Schema.Create().SetSku("B-1").SetProductName("Bolt").SetStockCount(1).SaveX(ctx)
+	removed := testClient.Test3Schema.Create().SetSku("B-2").SaveX(ctx)
+	type response struct {
+		Committed bool          `json:"committed"`
+		Results   []batchResult `json:"results"`
+	}
+	send := func(changes string) (int, response) {
+		rec := httptest.NewRecorder()
+		batchSaveHandler(rec, httptest.NewRequest(http.MethodPost, "/entities/batch", strings.NewReader(`{"changes": `+changes+`}`)))
+		var body response
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("response is not JSON: %v: %s", err, rec.Body.String())
+		}
+		return rec.Code, body
+	}
+	statuses := func(body response) string {
+		var s []string
+		for _, r := range body.Results {
+			s = append(s, r.Status)
+		}
+		return strings.Join(s, " ")
+	}
+
+	code, body := send(fmt.Sprintf(`[
+		{"entity": "test3schema", "type": "insert", "key": "_new1", "data": {"sku": "B-3", "retail_price": 2}},
+		{"entity": "Test3Schema", "type": "update", "key": %d, "version": 1, "data": {"stock_count": 5}},
+		{"entity": "test3schema", "type": "remove", "key": %d, "version": 1}]`, existing.ID, removed.ID))
+	if code != http.StatusOK || !body.Committed || statuses(body) != "applied applied applied" {
+		t.Fatalf("expected the batch to commit, got %d %+v", code, body)
+	}
+	if body.Results[0].Key == "_new1" || body.Results[0].Data["sku"] != "B-3" || body.Results[1].Data["stock_count"] != 5.0 || body.Results[1].Data["version"] != 2.0 {
+		t.Errorf("expected the saved records in the results, got %+v", body.Results)
+	}
+	if n := testClient.Test3Schema.Query().CountX(ctx); n != 2 {
+		t.Errorf("expected 2 records after the batch, got %d", n)
+	}
+
+	code, body = send(fmt.Sprintf(`[
+		{"entity": "test3schema", "type": "update", "key": %d, "version": 2, "data": {"stock_count": 9}},
+		{"entity": "test3schema", "type": "insert", "data": {"sku": "B-4"}},
+		{"entity": "test3schema", "type": "insert", "data": {"sku": "B-1"}},
+		{"entity": "test3schema", "type": "remove", "key": %d, "version": 3}]`, existing.ID, existing.ID))
+	if code != http.StatusConflict || body.Committed || statuses(body) != "rolled_back rolled_back failed skipped" {
+		t.Fatalf("expected the duplicate sku to roll the batch back with 409, got %d %+v", code, body)
+	}
+	if stored := testClient.Test3Schema.GetX(ctx, existing.ID); stored.StockCount != 5 {
+		t.Errorf("expected the update to be rolled back, got stock_count %d", stored.StockCount)
+	}
+	if n := testClient.Test3Schema.Query().CountX(ctx); n != 2 {
+		t.Errorf("expected the insert to be rolled back, got %d records", n)
+	}
+
+	code, body = send(fmt.Sprintf(`[
+		{"entity": "test3schema", "type": "insert", "data": {"sku": "B-5"}},
+		{"entity": "test3schema", "type": "update", "key": %d, "version": 1, "data": {"stock_count": 0}}]`, existing.ID))
+	if code != http.StatusConflict || statuses(body) != "rolled_back failed" || body.Results[1].Current["version"] != 2.0 {
+		t.Fatalf("expected a stale version to roll the batch back with 409 and the current record, got %d %+v", code, body)
+	}
+
+	code, body = send(fmt.Sprintf(`[
+		{"entity": "test3schema", "type": "remove", "key": %d, "version": 2},
+		{"entity": "test3schema", "type": "update", "key": %d, "version": 2, "data": {"stock_count": "many"}},
+		{"entity": "test3schema", "type": "update", "key": %d, "data": {}},
+		{"entity": "nosuchentity", "type": "insert", "data": {}},
+		{"entity": "test3schema", "type": "upsert", "data": {}}]`, existing.ID, existing.ID, existing.ID))
+	if code != http.StatusBadRequest || statuses(body) != "skipped failed failed failed failed" {
+		t.Fatalf("expected every invalid change reported with 400, got %d %+v", code, body)
+	}
+	if len(body.Results[1].Errors) != 1 || body.Results[1].Errors[0].Field != "stock_count" {
+		t.Errorf("expected the stock_count error, got %+v", body.Results[1])
+	}
+	if !testClient.Test3Schema.Query().Where(test3schema.ID(existing.ID)).ExistX(ctx) {
+		t.Error("expected nothing to run when a change is invalid")
+	}
+
+	code, body = send(`[{"entity": "test3schema", "type": "remove", "key": 999999, "version": 1}]`)
+	if code != http.StatusNotFound || statuses(body) != "failed" {
+		t.Errorf("expected 404 for removing a missing record, got %d %+v", code, body)
+	}
+}